@@ -0,0 +1,413 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/core"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"github.com/kN6jq/fingerScan/pkg/logger"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// fingerprintCommands 指纹库管理子命令
+var fingerprintCommands = []struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}{
+	{"convert", "将其他工具的指纹库转换为本工具格式", runConvert},
+	{"lint", "检查指纹库中的无效和重复规则", runLint},
+	{"test", "使用样本响应测试指纹规则", runTest},
+	{"list", "列出指纹库中的全部CMS及规则数量", runList},
+	{"search", "按名称或关键字查找CMS", runSearch},
+	{"show", "显示指定CMS的全部规则", runShow},
+	{"suggest", "根据正负样本生成候选指纹", runSuggest},
+}
+
+// runFingerprintCommand 执行 fp 子命令
+func runFingerprintCommand(args []string) int {
+	if len(args) > 0 {
+		for _, cmd := range fingerprintCommands {
+			if cmd.name == args[0] {
+				return cmd.run(args[1:])
+			}
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "用法: fingerScan fp <命令> [参数]")
+	for _, cmd := range fingerprintCommands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	return 1
+}
+
+// runConvert 转换外部指纹库
+func runConvert(args []string) int {
+	fs := flag.NewFlagSet("fp convert", flag.ExitOnError)
+	format := fs.String("format", "", "输入格式(wappalyzer/fingerprinthub/tidefinger/goby/nuclei)，为空时自动识别")
+	output := fs.String("o", "", "输出文件，为空时输出到标准输出")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: fingerScan fp convert [参数] <文件或目录>...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := core.ConvertFingerprintPaths(fs.Args(), *format)
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	var dbs []*model.FingerprintDB
+	var skipped, partial int
+	for _, file := range files {
+		for _, note := range file.Notes {
+			if note.Partial {
+				fmt.Fprintf(os.Stderr, "未提取版本: %s\n", note.Message)
+				partial++
+			} else {
+				fmt.Fprintf(os.Stderr, "无法转换: %s\n", note.Message)
+				skipped++
+			}
+		}
+		dbs = append(dbs, file.DB)
+	}
+
+	merged := core.MergeFingerprints(dbs...)
+	data, err := utils.MarshalIndent(merged)
+	if err != nil {
+		logger.Error("序列化指纹失败: %v", err)
+		return 1
+	}
+
+	if *output == "" {
+		fmt.Print(string(data))
+	} else if err := os.WriteFile(*output, data, 0644); err != nil {
+		logger.Error("写入 %s 失败: %v", *output, err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "转换完成: %d 条指纹，%d 条规则无法转换，%d 条规则未提取版本\n", len(merged.Fingerprints), skipped, partial)
+	return 0
+}
+
+// runLint 检查指纹库，存在错误时返回非零退出码
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("fp lint", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "警告也视为失败")
+	jsonOutput := fs.Bool("json", false, "以JSON格式输出")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: fingerScan fp lint [参数] [文件或目录]...")
+		fmt.Fprintln(os.Stderr, "未指定文件时检查内置指纹库")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	db, err := loadFingerprintArgs(fs.Args())
+	if err != nil {
+		logger.Error("加载指纹失败: %v", err)
+		return 1
+	}
+
+	issues := core.LintFingerprints(db)
+	var errors, warnings int
+	for _, issue := range issues {
+		if issue.Level == core.LintError {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+
+	if *jsonOutput {
+		data, err := utils.MarshalIndent(issues)
+		if err != nil {
+			logger.Error("序列化结果失败: %v", err)
+			return 1
+		}
+		fmt.Print(string(data))
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("[%s] #%d (%s): %s\n", strings.ToUpper(issue.Level), issue.Index, issue.CMS, issue.Message)
+		}
+		fmt.Printf("共检查 %d 条指纹: %d 个错误, %d 个警告\n", len(db.Fingerprints), errors, warnings)
+	}
+
+	if errors > 0 || (*strict && warnings > 0) {
+		return 1
+	}
+	return 0
+}
+
+// runTest 使用指纹中配置的样本测试规则，存在失败时返回非零退出码
+func runTest(args []string) int {
+	fs := flag.NewFlagSet("fp test", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "没有样本的规则也视为失败")
+	verbose := fs.Bool("v", false, "列出没有样本的规则")
+	jsonOutput := fs.Bool("json", false, "以JSON格式输出")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: fingerScan fp test [参数] [文件或目录]...")
+		fmt.Fprintln(os.Stderr, "未指定文件时测试内置指纹库")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	db, err := loadFingerprintArgs(fs.Args())
+	if err != nil {
+		logger.Error("加载指纹失败: %v", err)
+		return 1
+	}
+
+	results := core.TestFingerprints(db)
+	var failed, missing, passed int
+	for _, result := range results {
+		switch {
+		case len(result.Failures) > 0:
+			failed++
+		case result.NoFixtures:
+			missing++
+		default:
+			passed++
+		}
+	}
+
+	if *jsonOutput {
+		data, err := utils.MarshalIndent(results)
+		if err != nil {
+			logger.Error("序列化结果失败: %v", err)
+			return 1
+		}
+		fmt.Print(string(data))
+	} else {
+		for _, result := range results {
+			for _, failure := range result.Failures {
+				fmt.Printf("[FAIL] #%d %s (%s): %s\n", result.Index, result.Rule, result.CMS, failure)
+			}
+			if result.NoFixtures && *verbose {
+				fmt.Printf("[SKIP] #%d %s (%s): 没有测试样本\n", result.Index, result.Rule, result.CMS)
+			}
+		}
+		fmt.Printf("共 %d 条指纹: %d 条通过, %d 条失败, %d 条没有样本\n", len(results), passed, failed, missing)
+	}
+
+	if failed > 0 || (*strict && missing > 0) {
+		return 1
+	}
+	return 0
+}
+
+// runList 列出指纹库中的全部CMS
+func runList(args []string) int {
+	fs := flag.NewFlagSet("fp list", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "以JSON格式输出")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: fingerScan fp list [参数] [文件或目录]...")
+		fmt.Fprintln(os.Stderr, "未指定文件时列出内置指纹库")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	db, err := loadFingerprintArgs(fs.Args())
+	if err != nil {
+		logger.Error("加载指纹失败: %v", err)
+		return 1
+	}
+	return printSummaries(core.ListFingerprints(db), *jsonOutput)
+}
+
+// runSearch 按名称、关键字或元数据查找CMS
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("fp search", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "以JSON格式输出")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: fingerScan fp search [参数] <查询词> [文件或目录]...")
+		fmt.Fprintln(os.Stderr, "在名称、关键字、表达式、分类和标签中查找，不区分大小写；未指定文件时查找内置指纹库")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	db, err := loadFingerprintArgs(fs.Args()[1:])
+	if err != nil {
+		logger.Error("加载指纹失败: %v", err)
+		return 1
+	}
+	return printSummaries(core.SearchFingerprints(db, fs.Arg(0)), *jsonOutput)
+}
+
+// runShow 显示指定CMS的全部规则
+func runShow(args []string) int {
+	fs := flag.NewFlagSet("fp show", flag.ExitOnError)
+	jsonOutput := fs.Bool("json", false, "以指纹文件格式输出JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: fingerScan fp show [参数] <CMS名称> [文件或目录]...")
+		fmt.Fprintln(os.Stderr, "未指定文件时使用内置指纹库")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	db, err := loadFingerprintArgs(fs.Args()[1:])
+	if err != nil {
+		logger.Error("加载指纹失败: %v", err)
+		return 1
+	}
+
+	indexes, fingerprints := core.FindFingerprints(db, fs.Arg(0))
+	if len(fingerprints) == 0 {
+		logger.Error("未找到 %s 的指纹", fs.Arg(0))
+		return 1
+	}
+
+	if *jsonOutput {
+		data, err := utils.MarshalIndent(&model.FingerprintDB{Fingerprints: fingerprints})
+		if err != nil {
+			logger.Error("序列化指纹失败: %v", err)
+			return 1
+		}
+		fmt.Print(string(data))
+		return 0
+	}
+
+	for i, fp := range fingerprints {
+		fmt.Printf("#%d %s\n", indexes[i], describeFingerprint(fp))
+		data, err := utils.MarshalIndent(fp)
+		if err != nil {
+			logger.Error("序列化指纹失败: %v", err)
+			return 1
+		}
+		fmt.Print(string(data))
+	}
+	return 0
+}
+
+// describeFingerprint 生成规则的单行说明
+func describeFingerprint(fp model.Fingerprint) string {
+	var desc string
+	if fp.Method == "expr" {
+		desc = "expr " + fp.Expr
+	} else {
+		desc = fmt.Sprintf("%s %s: %s", fp.Method, fp.Location, strings.Join(fp.Keywords, " && "))
+	}
+	if fp.Path != "" {
+		desc = fp.Path + " " + desc
+	}
+	return desc
+}
+
+// printSummaries 输出CMS概况列表
+func printSummaries(summaries []core.CMSSummary, jsonOutput bool) int {
+	if jsonOutput {
+		data, err := utils.MarshalIndent(summaries)
+		if err != nil {
+			logger.Error("序列化结果失败: %v", err)
+			return 1
+		}
+		fmt.Print(string(data))
+		return 0
+	}
+
+	var rules int
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CMS\t规则数\t分类\t重要程度\t")
+	for _, summary := range summaries {
+		rules += summary.Rules
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t\n", summary.Name, summary.Rules, summary.Category, summary.Importance)
+	}
+	w.Flush()
+	fmt.Printf("共 %d 个CMS, %d 条指纹\n", len(summaries), rules)
+	return 0
+}
+
+// runSuggest 请求URL或读取保存的样本，输出在全部正样本上命中且不命中负样本的候选指纹
+func runSuggest(args []string) int {
+	fs := flag.NewFlagSet("fp suggest", flag.ExitOnError)
+	name := fs.String("name", "", "候选指纹的CMS名称")
+	positive := fs.String("p", "", "运行该产品的URL或样本文件，多个用逗号分隔")
+	negative := fs.String("n", "", "无关的URL或样本文件，多个用逗号分隔")
+	proxy := fs.String("proxy", "", "请求URL时使用的代理")
+	max := fs.Int("max", core.DefaultMaxSuggestions, "每类候选规则的最大数量")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: fingerScan fp suggest -name <CMS名称> -p <URL或样本>[,...] [-n <URL或样本>[,...]]")
+		fmt.Fprintln(os.Stderr, "以http://或https://开头的参数会被请求，其余作为fp test使用的样本文件读取")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if *name == "" || *positive == "" {
+		fs.Usage()
+		return 1
+	}
+
+	client := core.NewHTTPClient(*proxy)
+	positives, positiveFiles, err := loadSuggestSamples(client, utils.SplitList(*positive))
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+	negatives, negativeFiles, err := loadSuggestSamples(client, utils.SplitList(*negative))
+	if err != nil {
+		logger.Error("%v", err)
+		return 1
+	}
+
+	suggestions := core.SuggestFingerprints(*name, positives, negatives, *max)
+	// 样本文件可以直接作为候选规则的测试样本
+	if len(positiveFiles) > 0 {
+		for i := range suggestions {
+			suggestions[i].Fixtures = &model.Fixtures{Positive: positiveFiles, Negative: negativeFiles}
+		}
+	}
+
+	data, err := utils.MarshalIndent(&model.FingerprintDB{Fingerprints: suggestions})
+	if err != nil {
+		logger.Error("序列化指纹失败: %v", err)
+		return 1
+	}
+	fmt.Print(string(data))
+	fmt.Fprintf(os.Stderr, "根据 %d 个正样本和 %d 个负样本生成 %d 条候选指纹\n", len(positives), len(negatives), len(suggestions))
+	return 0
+}
+
+// loadSuggestSamples 请求URL或读取样本文件，同时返回其中的样本文件路径
+func loadSuggestSamples(client *core.HTTPClient, sources []string) ([]*model.HTTPResponse, []string, error) {
+	var responses []*model.HTTPResponse
+	var files []string
+	for _, source := range sources {
+		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+			resp, err := client.DoRequest(source)
+			if err != nil {
+				return nil, nil, fmt.Errorf("请求 %s 失败: %v", source, err)
+			}
+			responses = append(responses, resp)
+			continue
+		}
+		resp, err := core.LoadSample(source)
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取样本 %s 失败: %v", source, err)
+		}
+		responses = append(responses, resp)
+		files = append(files, source)
+	}
+	return responses, files, nil
+}
+
+// loadFingerprintArgs 加载命令行指定的指纹文件，未指定时使用内置指纹库
+// 与扫描时不同，这里不做去重，以便检查出重复规则
+func loadFingerprintArgs(paths []string) (*model.FingerprintDB, error) {
+	if len(paths) == 0 {
+		return core.LoadFingerprints()
+	}
+	return core.LoadFingerprintFiles(paths)
+}
@@ -18,25 +18,74 @@ var (
 		output string
 		thread int
 		proxy  string
+
+		fingerprints string
+		replace      bool
+		watch        bool
+
+		compact    bool
+		active     bool
+		scripts    int
+		scriptSize int
+		probeMaps  bool
+		confidence int
+		importance string
+		categories string
+		tags       string
+		ruleStats  string
 	}{}
 )
 
 func init() {
 	flag.StringVar(&config.file, "f", "", "待识别的文件")
 	flag.StringVar(&config.url, "u", "", "待识别的url")
-	flag.StringVar(&config.output, "o", "", "保存的文件名(json或csv)")
+	flag.StringVar(&config.output, "o", "", "保存的文件名(json或xlsx)")
 	flag.IntVar(&config.thread, "t", 100, "扫描线程")
 	flag.StringVar(&config.proxy, "p", "", "代理")
-	flag.Parse()
+	flag.StringVar(&config.fingerprints, "fp", "", "外部指纹文件或目录，多个用逗号分隔")
+	flag.BoolVar(&config.replace, "fp-replace", false, "使用外部指纹替换内置指纹")
+	flag.BoolVar(&config.watch, "watch", false, "监视外部指纹文件，修改后在扫描过程中热加载")
+	flag.BoolVar(&config.compact, "compact", false, "控制台不显示命中证据")
+	flag.BoolVar(&config.active, "active", false, "启用主动探测，对每个主机请求指纹中的额外路径")
+	flag.IntVar(&config.scripts, "js", 0, "每个页面抓取并匹配的同源脚本数量，0为不抓取")
+	flag.IntVar(&config.scriptSize, "js-size", 2048, "单个脚本的最大读取大小(KB)")
+	flag.BoolVar(&config.probeMaps, "js-map", false, "脚本没有声明sourceMappingURL时也尝试请求同名的.map文件")
+	flag.IntVar(&config.confidence, "min-confidence", core.DefaultMinConfidence, "计为命中的最低置信度(1-100)，0表示使用默认值")
+	flag.StringVar(&config.importance, "importance", "medium", "列入重点资产的最低重要程度(info/low/medium/high/critical)")
+	flag.StringVar(&config.categories, "category", "", "只输出包含指定分类的结果，多个用逗号分隔")
+	flag.StringVar(&config.tags, "tag", "", "只输出包含指定标签的结果，多个用逗号分隔")
+	flag.StringVar(&config.ruleStats, "rule-stats", "", "保存规则命中统计到JSON文件，为 - 时打印统计表")
 }
 
 func main() {
+	// 指纹库管理子命令
+	if len(os.Args) > 1 && os.Args[1] == "fp" {
+		os.Exit(runFingerprintCommand(os.Args[2:]))
+	}
+
+	flag.Parse()
 	startTime := time.Now()
 
 	scanConfig := core.ScanConfig{
 		ThreadCount: config.thread,
 		OutputFile:  config.output,
 		ProxyURL:    config.proxy,
+
+		FingerprintFiles:    utils.SplitList(config.fingerprints),
+		ReplaceFingerprints: config.replace,
+		WatchFingerprints:   config.watch,
+
+		Compact:       config.compact,
+		Active:        config.active,
+		MinConfidence: config.confidence,
+		MinImportance: config.importance,
+		Categories:    utils.SplitList(config.categories),
+		Tags:          utils.SplitList(config.tags),
+		RuleStatsFile: config.ruleStats,
+
+		FetchScripts:    config.scripts,
+		MaxScriptSize:   int64(config.scriptSize) << 10,
+		ProbeSourceMaps: config.probeMaps,
 	}
 
 	var urls []string
@@ -52,12 +101,12 @@ func main() {
 
 	scanner, err := core.NewScanner(urls, scanConfig)
 	if err != nil {
-		logger.Error("初始化扫描器失败:", err)
+		logger.Error("初始化扫描器失败: %v", err)
 		os.Exit(1)
 	}
 
 	if err := scanner.Start(); err != nil {
-		logger.Error("扫描过程出错:", err)
+		logger.Error("扫描过程出错: %v", err)
 		os.Exit(1)
 	}
 
@@ -3,6 +3,7 @@ package fingerScan
 import (
 	"github.com/kN6jq/fingerScan/internal/core"
 	"github.com/kN6jq/fingerScan/internal/model"
+	"time"
 )
 
 // ScanConfig 扫描配置
@@ -11,11 +12,40 @@ type ScanConfig struct {
 	OutputFile  string // 输出文件
 	ProxyURL    string // 代理URL
 	Silent      bool   // 是否禁用输出
+
+	FingerprintFiles    []string // 外部指纹文件或目录
+	ReplaceFingerprints bool     // 外部指纹替换内置指纹而非追加
+
+	WatchFingerprints bool          // 监视外部指纹文件并在修改后热加载
+	WatchInterval     time.Duration // 检查指纹文件修改的间隔，默认5秒
+
+	Compact bool // 控制台输出不显示命中证据
+	Active  bool // 对每个主机发起主动探测请求
+
+	FetchScripts    int   // 每个页面抓取的同源脚本数量上限，0时不抓取
+	MaxScriptSize   int64 // 单个脚本的最大读取字节数，默认2MB
+	ProbeSourceMaps bool  // 脚本没有声明sourceMappingURL时也尝试请求同名的.map文件
+
+	MinConfidence int      // 计为命中的最低置信度，0时使用默认值50
+	MinImportance string   // 列入重点资产的最低重要程度，默认medium
+	Categories    []string // 只输出包含这些分类的结果
+	Tags          []string // 只输出包含这些标签的结果
+
+	RuleStatsFile string // 保存规则命中统计的JSON文件，为 - 时打印统计表
 }
 
 // ScanResult 扫描结果
 type ScanResult = model.ScanResult
 
+// Technology 识别出的技术及版本
+type Technology = model.Technology
+
+// Evidence 规则命中的证据
+type Evidence = model.Evidence
+
+// RuleStats 规则命中统计
+type RuleStats = model.RuleStats
+
 // Scanner 指纹扫描器接口
 type Scanner struct {
 	scanner *core.Scanner
@@ -28,6 +58,26 @@ func NewScanner(urls []string, config ScanConfig) (*Scanner, error) {
 		OutputFile:  config.OutputFile,
 		ProxyURL:    config.ProxyURL,
 		Silent:      config.Silent,
+
+		FingerprintFiles:    config.FingerprintFiles,
+		ReplaceFingerprints: config.ReplaceFingerprints,
+
+		WatchFingerprints: config.WatchFingerprints,
+		WatchInterval:     config.WatchInterval,
+
+		Compact: config.Compact,
+		Active:  config.Active,
+
+		FetchScripts:    config.FetchScripts,
+		MaxScriptSize:   config.MaxScriptSize,
+		ProbeSourceMaps: config.ProbeSourceMaps,
+
+		MinConfidence: config.MinConfidence,
+		MinImportance: config.MinImportance,
+		Categories:    config.Categories,
+		Tags:          config.Tags,
+
+		RuleStatsFile: config.RuleStatsFile,
 	}
 
 	s, err := core.NewScanner(urls, coreConfig)
@@ -43,6 +93,16 @@ func (s *Scanner) Start() error {
 	return s.scanner.Start()
 }
 
+// RuleStats 返回扫描过程中各规则的命中统计
+func (s *Scanner) RuleStats() RuleStats {
+	return s.scanner.RuleStats()
+}
+
+// Reload 重新加载外部指纹文件，加载失败时保留原有指纹
+func (s *Scanner) Reload() error {
+	return s.scanner.Reload()
+}
+
 // ScanSingleURL 扫描单个URL
 func ScanSingleURL(url string, proxy string) (*ScanResult, error) {
 	config := ScanConfig{
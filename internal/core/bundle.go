@@ -0,0 +1,187 @@
+package core
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// defaultMaxScriptSize 单个脚本默认的最大读取字节数
+const defaultMaxScriptSize = 2 << 20
+
+// 脚本缓存的默认容量，超出时淘汰最久未使用的URL
+const (
+	defaultScriptCacheBytes = 64 << 20 // 缓存的脚本内容总字节数上限
+	defaultScriptCacheURLs  = 10000    // 缓存的URL数量上限
+)
+
+// scriptCache 已抓取的脚本，按URL记录内容哈希，相同内容的脚本只保存一份
+// 不同主机常部署相同的前端包，按哈希去重可以避免重复占用内存
+type scriptCache struct {
+	mu     sync.Mutex
+	lru    *list.List                // 按最近使用排列的URL，元素为 *scriptEntry
+	urls   map[string]*list.Element  // URL -> 缓存项
+	bodies map[string]*scriptContent // 内容哈希 -> 脚本内容
+	size   int                       // 缓存的脚本内容总字节数
+
+	maxBytes int // 脚本内容总字节数上限，0时使用默认值
+	maxURLs  int // URL数量上限，0时使用默认值
+}
+
+// scriptEntry URL对应的内容哈希，抓取失败时为空
+type scriptEntry struct {
+	url  string
+	hash string
+}
+
+// scriptContent 脚本内容及引用它的URL数量
+type scriptContent struct {
+	body string
+	refs int
+}
+
+// get 获取缓存的脚本，ok表示该URL已经抓取过
+func (c *scriptCache) get(scriptURL string) (body string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.urls[scriptURL]
+	if !ok {
+		return "", false
+	}
+	c.lru.MoveToFront(elem)
+	if hash := elem.Value.(*scriptEntry).hash; hash != "" {
+		body = c.bodies[hash].body
+	}
+	return body, true
+}
+
+// put 缓存脚本内容，body为空表示抓取失败，淘汰前不再重试
+func (c *scriptCache) put(scriptURL, body string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.urls == nil {
+		c.lru = list.New()
+		c.urls = make(map[string]*list.Element)
+		c.bodies = make(map[string]*scriptContent)
+		if c.maxBytes <= 0 {
+			c.maxBytes = defaultScriptCacheBytes
+		}
+		if c.maxURLs <= 0 {
+			c.maxURLs = defaultScriptCacheURLs
+		}
+	}
+	if elem, ok := c.urls[scriptURL]; ok {
+		c.remove(elem)
+	}
+
+	entry := &scriptEntry{url: scriptURL}
+	if body != "" {
+		sum := sha256.Sum256([]byte(body))
+		entry.hash = hex.EncodeToString(sum[:])
+		content, ok := c.bodies[entry.hash]
+		if !ok {
+			content = &scriptContent{body: body}
+			c.bodies[entry.hash] = content
+			c.size += len(body)
+		}
+		content.refs++
+	}
+	c.urls[scriptURL] = c.lru.PushFront(entry)
+
+	for c.lru.Len() > 1 && (c.lru.Len() > c.maxURLs || c.size > c.maxBytes) {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove 删除一个URL，没有URL引用的内容同时释放
+func (c *scriptCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*scriptEntry)
+	delete(c.urls, entry.url)
+	if entry.hash == "" {
+		return
+	}
+	content := c.bodies[entry.hash]
+	if content.refs--; content.refs == 0 {
+		delete(c.bodies, entry.hash)
+		c.size -= len(content.body)
+	}
+}
+
+// bundle 抓取到的脚本
+type bundle struct {
+	url  string
+	body string
+}
+
+// fetchScripts 抓取页面引用的同源脚本，内容作为js位置参与匹配
+func (s *Scanner) fetchScripts(resp *model.HTTPResponse) []bundle {
+	var bundles []bundle
+	var bodies []string
+	for _, scriptURL := range sameOriginScripts(resp, s.config.FetchScripts) {
+		if body := s.fetchAsset(scriptURL); body != "" {
+			bundles = append(bundles, bundle{url: scriptURL, body: body})
+			bodies = append(bodies, body)
+		}
+	}
+	resp.JS = strings.Join(bodies, "\n")
+	return bundles
+}
+
+// fetchAsset 获取脚本，优先使用缓存，获取失败时返回空
+func (s *Scanner) fetchAsset(assetURL string) string {
+	if body, ok := s.scripts.get(assetURL); ok {
+		return body
+	}
+
+	body, _ := s.httpClient.FetchScript(assetURL, s.maxScriptSize())
+	// 单页应用对不存在的路径也会返回首页HTML
+	if strings.HasPrefix(strings.TrimSpace(body), "<") {
+		body = ""
+	}
+	s.scripts.put(assetURL, body)
+	return body
+}
+
+// maxScriptSize 返回单个脚本的最大读取字节数
+func (s *Scanner) maxScriptSize() int64 {
+	if s.config.MaxScriptSize > 0 {
+		return s.config.MaxScriptSize
+	}
+	return defaultMaxScriptSize
+}
+
+// sameOriginScripts 解析script标签的src，返回与页面同源的前max个脚本URL
+func sameOriginScripts(resp *model.HTTPResponse, max int) []string {
+	pageURL := resp.FinalURL
+	if pageURL == "" {
+		pageURL = resp.URL
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, src := range resp.Scripts {
+		if len(urls) >= max {
+			break
+		}
+		ref, err := url.Parse(src)
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref)
+		u.Fragment = ""
+		if u.Scheme != base.Scheme || u.Host != base.Host || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		urls = append(urls, u.String())
+	}
+	return urls
+}
@@ -0,0 +1,37 @@
+package core
+
+import "testing"
+
+func TestScriptCacheDeduplicatesAndEvicts(t *testing.T) {
+	c := &scriptCache{maxBytes: 10, maxURLs: 3}
+
+	c.put("http://a/app.js", "12345")
+	c.put("http://b/app.js", "12345")
+	if c.size != 5 || len(c.bodies) != 1 {
+		t.Fatalf("相同内容应只保存一份: size=%d bodies=%d", c.size, len(c.bodies))
+	}
+	if body, ok := c.get("http://b/app.js"); !ok || body != "12345" {
+		t.Fatalf("get() = %q, %v", body, ok)
+	}
+
+	// 失败记录占用URL名额，但不占用字节
+	c.put("http://c/app.js", "")
+	if body, ok := c.get("http://c/app.js"); !ok || body != "" {
+		t.Fatalf("失败记录 get() = %q, %v", body, ok)
+	}
+
+	// 超出URL数量，淘汰最久未使用的 a，内容仍被 b 引用
+	c.put("http://d/app.js", "abc")
+	if _, ok := c.get("http://a/app.js"); ok {
+		t.Error("http://a/app.js 应被淘汰")
+	}
+	if c.size != 8 {
+		t.Errorf("size = %d, want 8", c.size)
+	}
+
+	// 超出字节上限，依次淘汰 b、c、d，直到只剩新加入的URL
+	c.put("http://e/app.js", "abcdefghij")
+	if c.lru.Len() != 1 || c.size != 10 || len(c.bodies) != 1 {
+		t.Errorf("淘汰后 urls=%d size=%d bodies=%d, want 1 10 1", c.lru.Len(), c.size, len(c.bodies))
+	}
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"sort"
+	"strings"
+)
+
+// CMSSummary 指纹库中某一CMS的规则概况
+type CMSSummary struct {
+	Name       string   `json:"name"`                 // CMS名称
+	Rules      int      `json:"rules"`                // 规则数量
+	Category   string   `json:"category,omitempty"`   // 分类
+	Importance string   `json:"importance,omitempty"` // 最高重要程度
+	Tags       []string `json:"tags,omitempty"`       // 标签
+}
+
+// ListFingerprints 按名称列出指纹库中的全部CMS及其规则数量
+func ListFingerprints(db *model.FingerprintDB) []CMSSummary {
+	return summarizeFingerprints(db, nil)
+}
+
+// SearchFingerprints 查找名称、关键字、表达式或元数据中包含查询词的CMS，不区分大小写
+func SearchFingerprints(db *model.FingerprintDB, query string) []CMSSummary {
+	query = strings.ToLower(query)
+	matched := make(map[string]bool)
+	for _, fp := range db.Fingerprints {
+		if !matched[fp.CMS] && fingerprintContains(fp, query) {
+			matched[fp.CMS] = true
+		}
+	}
+	return summarizeFingerprints(db, matched)
+}
+
+// FindFingerprints 获取指定CMS的全部规则，名称完全匹配失败时使用第一个不区分大小写相同的名称
+// 返回的序号为规则在指纹库中的位置
+func FindFingerprints(db *model.FingerprintDB, name string) ([]int, []model.Fingerprint) {
+	indexes := fingerprintIndexes(db, name)
+	if len(indexes) == 0 {
+		for _, fp := range db.Fingerprints {
+			if strings.EqualFold(fp.CMS, name) {
+				name = fp.CMS
+				indexes = fingerprintIndexes(db, name)
+				break
+			}
+		}
+	}
+	return indexes, GetFingerprint(db, name)
+}
+
+// summarizeFingerprints 汇总CMS的规则概况，only不为nil时只汇总其中的CMS
+func summarizeFingerprints(db *model.FingerprintDB, only map[string]bool) []CMSSummary {
+	var summaries []CMSSummary
+	positions := make(map[string]int)
+	for _, fp := range db.Fingerprints {
+		if only != nil && !only[fp.CMS] {
+			continue
+		}
+		pos, ok := positions[fp.CMS]
+		if !ok {
+			pos = len(summaries)
+			positions[fp.CMS] = pos
+			// 以第一条规则的重要程度为初值，空值按medium参与比较
+			summaries = append(summaries, CMSSummary{Name: fp.CMS, Importance: fp.Importance})
+		}
+
+		summary := &summaries[pos]
+		summary.Rules++
+		if summary.Category == "" {
+			summary.Category = fp.Category
+		}
+		if ImportanceLevel(fp.Importance) > ImportanceLevel(summary.Importance) {
+			summary.Importance = fp.Importance
+		}
+		summary.Tags = mergeStrings(summary.Tags, fp.Tags)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		a, b := strings.ToLower(summaries[i].Name), strings.ToLower(summaries[j].Name)
+		if a != b {
+			return a < b
+		}
+		return summaries[i].Name < summaries[j].Name
+	})
+	return summaries
+}
+
+// fingerprintContains 判断指纹的名称、关键字、表达式或元数据是否包含已转为小写的查询词
+func fingerprintContains(fp model.Fingerprint, query string) bool {
+	fields := []string{fp.CMS, fp.Expr, fp.Category, fp.Vendor, fp.Product, fp.CPE, fp.Path}
+	fields = append(fields, fp.Keywords...)
+	fields = append(fields, fp.Tags...)
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), query) {
+			return true
+		}
+	}
+	return false
+}
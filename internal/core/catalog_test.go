@@ -0,0 +1,41 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"reflect"
+	"testing"
+)
+
+func TestListFingerprintsImportance(t *testing.T) {
+	db := &model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		{CMS: "Low", Importance: ImportanceLow},
+		{CMS: "Low", Importance: ImportanceInfo},
+		{CMS: "Mixed", Importance: ImportanceLow},
+		{CMS: "Mixed"},
+		{CMS: "Mixed", Importance: ImportanceHigh},
+		{CMS: "Default"},
+	}}
+	want := map[string]string{"Low": ImportanceLow, "Mixed": ImportanceHigh, "Default": ""}
+	for _, summary := range ListFingerprints(db) {
+		if summary.Importance != want[summary.Name] {
+			t.Errorf("%s importance = %q, want %q", summary.Name, summary.Importance, want[summary.Name])
+		}
+	}
+}
+
+func TestFindFingerprints(t *testing.T) {
+	db := &model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		{CMS: "Jenkins", Keywords: []string{"a"}},
+		{CMS: "Other"},
+		{CMS: "Jenkins", Keywords: []string{"b"}},
+	}}
+	for _, name := range []string{"Jenkins", "jenkins"} {
+		indexes, fps := FindFingerprints(db, name)
+		if !reflect.DeepEqual(indexes, []int{0, 2}) || len(fps) != 2 || fps[1].Keywords[0] != "b" {
+			t.Errorf("FindFingerprints(%q) = %v, %v", name, indexes, fps)
+		}
+	}
+	if indexes, fps := FindFingerprints(db, "missing"); indexes != nil || fps != nil {
+		t.Errorf("FindFingerprints(missing) = %v, %v", indexes, fps)
+	}
+}
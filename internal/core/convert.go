@@ -0,0 +1,208 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"gopkg.in/yaml.v3"
+	"path/filepath"
+	"strings"
+)
+
+// 支持的指纹库格式
+const (
+	FormatNative         = "native"         // 本工具的finger.json
+	FormatWappalyzer     = "wappalyzer"     // Wappalyzer technologies.json
+	FormatFingerprintHub = "fingerprinthub" // FingerprintHub web_fingerprint_v3.json
+	FormatTideFinger     = "tidefinger"     // TideFinger cms/fofa 指纹表导出
+	FormatGoby           = "goby"           // Goby 规则导出
+	FormatNuclei         = "nuclei"         // nuclei technologies 模板
+)
+
+// ConvertNote 转换说明
+type ConvertNote struct {
+	Message string // 规则及原因
+	Partial bool   // 为true时规则已转换但未能提取版本，否则规则被跳过
+}
+
+// ConvertFingerprints 将指定格式的指纹数据转换为指纹库，返回无法完整表示的规则说明
+func ConvertFingerprints(format string, data []byte) (*model.FingerprintDB, []ConvertNote, error) {
+	switch format {
+	case FormatNative:
+		var db model.FingerprintDB
+		if err := json.Unmarshal(data, &db); err != nil {
+			return nil, nil, err
+		}
+		return &db, nil, nil
+	case FormatWappalyzer:
+		return ConvertWappalyzer(data)
+	case FormatFingerprintHub:
+		return skippedNotes(ConvertFingerprintHub(data))
+	case FormatTideFinger:
+		return skippedNotes(ConvertTideFinger(data))
+	case FormatGoby:
+		return skippedNotes(ConvertGoby(data))
+	case FormatNuclei:
+		return skippedNotes(ConvertNuclei(data))
+	}
+	return nil, nil, fmt.Errorf("不支持的指纹格式: %s", format)
+}
+
+// skippedNotes 将转换器返回的跳过说明包装为转换说明
+func skippedNotes(db *model.FingerprintDB, skipped []string, err error) (*model.FingerprintDB, []ConvertNote, error) {
+	notes := make([]ConvertNote, 0, len(skipped))
+	for _, message := range skipped {
+		notes = append(notes, ConvertNote{Message: message})
+	}
+	return db, notes, err
+}
+
+// detectYAMLFormat 识别YAML指纹，nuclei模板是带id和info的单个对象，FingerprintHub是规则列表
+func detectYAMLFormat(data []byte) string {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return ""
+	}
+	switch v := doc.(type) {
+	case map[string]interface{}:
+		if _, ok := v["id"]; ok {
+			return FormatNuclei
+		}
+		if _, ok := v["info"]; ok {
+			return FormatNuclei
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			return ""
+		}
+		item, ok := v[0].(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		for _, key := range []string{"name", "keyword", "favicon_hash", "request_method"} {
+			if _, ok := item[key]; ok {
+				return FormatFingerprintHub
+			}
+		}
+	}
+	return ""
+}
+
+// DetectFormat 根据文件名和内容识别指纹库格式，无法识别时返回空字符串
+func DetectFormat(filename string, data []byte) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return detectYAMLFormat(data)
+	}
+
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err == nil {
+		if _, ok := top["fingerprint"]; ok {
+			return FormatNative
+		}
+		if isWappalyzerData(top) {
+			return FormatWappalyzer
+		}
+		return ""
+	}
+
+	var items []map[string]json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil || len(items) == 0 {
+		return ""
+	}
+	has := func(keys ...string) bool {
+		for _, key := range keys {
+			if _, ok := items[0][key]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("request_method", "favicon_hash", "request_headers"):
+		return FormatFingerprintHub
+	case has("cms_name", "match_pattern", "keys"):
+		return FormatTideFinger
+	case has("rules", "rule", "rule_id"):
+		return FormatGoby
+	}
+	return ""
+}
+
+// exprCondition 生成单个表达式条件，值中的反斜杠和双引号被转义
+func exprCondition(location, op, value string) string {
+	return location + op + `"` + exprStringEscaper.Replace(value) + `"`
+}
+
+// exprStringEscaper 表达式字符串中需要转义的字符
+var exprStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// exprJoin 用逻辑运算符连接多个表达式，必要时加括号
+func exprJoin(op string, parts []string) string {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	wrapped := make([]string, len(parts))
+	for i, part := range parts {
+		if strings.Contains(part, "&&") || strings.Contains(part, "||") {
+			part = "(" + part + ")"
+		}
+		wrapped[i] = part
+	}
+	return strings.Join(wrapped, " "+op+" ")
+}
+
+// fofaFields FOFA查询语法字段与表达式位置的对应关系
+var fofaFields = map[string]string{
+	"title":       locationTitle,
+	"body":        locationBody,
+	"header":      locationHeader,
+	"banner":      locationHeader,
+	"server":      locationHeader,
+	"status_code": locationStatus,
+	"icon_hash":   locationIconHash,
+}
+
+// convertFOFAQuery 将FOFA风格的查询语句转换为表达式
+func convertFOFAQuery(query string) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(query) && query[end] != '"' {
+				if query[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(query) {
+				return "", fmt.Errorf("字符串未闭合")
+			}
+			sb.WriteString(query[i : end+1])
+			i = end + 1
+		case isExprIdentChar(c) && c != '-' && c != ':':
+			end := i
+			for end < len(query) && isExprIdentChar(query[end]) {
+				end++
+			}
+			field := strings.ToLower(query[i:end])
+			location, ok := fofaFields[field]
+			if !ok {
+				return "", fmt.Errorf("不支持的字段 %q", field)
+			}
+			sb.WriteString(location)
+			i = end
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	expr := sb.String()
+	if _, err := parseExpr(expr); err != nil {
+		return "", err
+	}
+	return expr, nil
+}
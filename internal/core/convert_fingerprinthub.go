@@ -0,0 +1,108 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"gopkg.in/yaml.v3"
+	"sort"
+	"strings"
+)
+
+// fingerprintHubRule FingerprintHub web_fingerprint_v3.json 或同结构YAML中的单条规则
+type fingerprintHubRule struct {
+	Name           string            `json:"name" yaml:"name"`
+	Path           string            `json:"path" yaml:"path"`
+	RequestMethod  string            `json:"request_method" yaml:"request_method"`
+	RequestHeaders map[string]string `json:"request_headers" yaml:"request_headers"`
+	RequestData    string            `json:"request_data" yaml:"request_data"`
+	StatusCode     int               `json:"status_code" yaml:"status_code"`
+	Headers        map[string]string `json:"headers" yaml:"headers"`
+	Keyword        []string          `json:"keyword" yaml:"keyword"`
+	FaviconHash    []string          `json:"favicon_hash" yaml:"favicon_hash"`
+}
+
+// ConvertFingerprintHub 转换FingerprintHub的JSON或YAML指纹
+// 规则内的状态码、响应头和关键字需要同时满足，非首页路径转换为主动探测规则
+func ConvertFingerprintHub(data []byte) (*model.FingerprintDB, []string, error) {
+	var rules []fingerprintHubRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		if yamlErr := yaml.Unmarshal(data, &rules); yamlErr != nil {
+			return nil, nil, err
+		}
+	}
+
+	db := &model.FingerprintDB{}
+	var skipped []string
+	for _, r := range rules {
+		fp, reason := convertFingerprintHubRule(r)
+		if reason != "" {
+			skipped = append(skipped, fmt.Sprintf("%s [%s]: %s", r.Name, r.Path, reason))
+			continue
+		}
+		db.Fingerprints = append(db.Fingerprints, fp)
+	}
+	return db, skipped, nil
+}
+
+// convertFingerprintHubRule 转换单条规则，无法表示时返回原因
+func convertFingerprintHubRule(r fingerprintHubRule) (model.Fingerprint, string) {
+	if len(r.FaviconHash) > 0 && (len(r.Headers) > 0 || len(r.Keyword) > 0) {
+		return model.Fingerprint{}, "favicon_hash 与响应头或关键字同时存在"
+	}
+
+	fp := model.Fingerprint{
+		CMS:            r.Name,
+		RequestHeaders: r.RequestHeaders,
+		RequestBody:    r.RequestData,
+		StatusCode:     r.StatusCode,
+	}
+	if r.Path != "/" {
+		fp.Path = r.Path
+	}
+	if method := strings.ToUpper(r.RequestMethod); method != "GET" {
+		fp.RequestMethod = method
+	}
+
+	// favicon_hash为md5，任一图标的哈希相等即命中
+	if len(r.FaviconHash) > 0 {
+		var parts []string
+		for _, hash := range r.FaviconHash {
+			parts = append(parts, exprCondition(locationIconHash, "=", strings.ToLower(hash)))
+		}
+		fp.Method = methodExpr
+		fp.Expr = exprJoin("||", parts)
+		return fp, ""
+	}
+
+	switch {
+	case len(r.Headers) == 0 && len(r.Keyword) > 0:
+		// 只有body关键字时直接使用keyword规则
+		fp.Method = methodKeyword
+		fp.Location = locationBody
+		fp.Keywords = r.Keyword
+		return fp, ""
+	case len(r.Headers) == 0:
+		if r.StatusCode == 0 {
+			return model.Fingerprint{}, "规则为空"
+		}
+		return fp, ""
+	}
+
+	var parts []string
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, exprCondition(headerLocationPrefix+name, "=", r.Headers[name]))
+	}
+	for _, keyword := range r.Keyword {
+		parts = append(parts, exprCondition(locationBody, "=", keyword))
+	}
+
+	fp.Method = methodExpr
+	fp.Expr = exprJoin("&&", parts)
+	return fp, ""
+}
@@ -0,0 +1,83 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"strings"
+)
+
+// gobyRule Goby规则导出的单条规则
+// rule为FOFA风格的查询语句；rules为二维数组，外层为或、内层为与
+type gobyRule struct {
+	Name    string          `json:"name"`
+	Product string          `json:"product"`
+	Rule    string          `json:"rule"`
+	Rules   [][]gobyMatcher `json:"rules"`
+}
+
+// gobyMatcher Goby规则中的单个匹配条件
+type gobyMatcher struct {
+	Match   string `json:"match"`
+	Content string `json:"content"`
+}
+
+// gobyMatchLocations Goby匹配类型与表达式位置的对应关系
+var gobyMatchLocations = map[string]string{
+	"body_contains":   locationBody,
+	"title_contains":  locationTitle,
+	"header_contains": locationHeader,
+	"banner_contains": locationHeader,
+	"server_contains": locationHeader,
+}
+
+// ConvertGoby 转换Goby规则导出
+func ConvertGoby(data []byte) (*model.FingerprintDB, []string, error) {
+	var rules []gobyRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, nil, err
+	}
+
+	db := &model.FingerprintDB{}
+	var skipped []string
+	for _, r := range rules {
+		name := r.Product
+		if name == "" {
+			name = r.Name
+		}
+
+		expr, err := convertGobyRule(r)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		db.Fingerprints = append(db.Fingerprints, model.Fingerprint{CMS: name, Method: methodExpr, Expr: expr})
+	}
+	return db, skipped, nil
+}
+
+// convertGobyRule 将规则转换为表达式
+func convertGobyRule(r gobyRule) (string, error) {
+	if r.Rule != "" {
+		return convertFOFAQuery(r.Rule)
+	}
+
+	var any []string
+	for _, group := range r.Rules {
+		var all []string
+		for _, m := range group {
+			location, ok := gobyMatchLocations[strings.ToLower(m.Match)]
+			if !ok {
+				return "", fmt.Errorf("不支持的匹配类型 %q", m.Match)
+			}
+			all = append(all, exprCondition(location, "=", m.Content))
+		}
+		if len(all) > 0 {
+			any = append(any, exprJoin("&&", all))
+		}
+	}
+	if len(any) == 0 {
+		return "", fmt.Errorf("规则为空")
+	}
+	return exprJoin("||", any), nil
+}
@@ -0,0 +1,243 @@
+package core
+
+import (
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"gopkg.in/yaml.v3"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// nucleiTemplate nuclei模板中与技术识别相关的字段
+type nucleiTemplate struct {
+	ID   string `yaml:"id"`
+	Info struct {
+		Name string `yaml:"name"`
+	} `yaml:"info"`
+	HTTP     []nucleiRequest `yaml:"http"`
+	Requests []nucleiRequest `yaml:"requests"`
+}
+
+// nucleiRequest nuclei模板中的单个HTTP请求
+type nucleiRequest struct {
+	Method            string            `yaml:"method"`
+	Path              []string          `yaml:"path"`
+	Raw               []string          `yaml:"raw"`
+	Body              string            `yaml:"body"`
+	Headers           map[string]string `yaml:"headers"`
+	MatchersCondition string            `yaml:"matchers-condition"`
+	Matchers          []nucleiMatcher   `yaml:"matchers"`
+}
+
+// nucleiMatcher nuclei匹配器
+type nucleiMatcher struct {
+	Name            string   `yaml:"name"`
+	Type            string   `yaml:"type"`
+	Part            string   `yaml:"part"`
+	Words           []string `yaml:"words"`
+	Regex           []string `yaml:"regex"`
+	Status          []int    `yaml:"status"`
+	Condition       string   `yaml:"condition"`
+	Negative        bool     `yaml:"negative"`
+	CaseInsensitive bool     `yaml:"case-insensitive"`
+}
+
+// ConvertNuclei 转换nuclei technologies模板，支持单个请求上的word/regex/status匹配器
+// 请求非首页路径时转换为主动探测规则
+// 多个YAML文档可以放在同一文件中
+func ConvertNuclei(data []byte) (*model.FingerprintDB, []string, error) {
+	db := &model.FingerprintDB{}
+	var skipped []string
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	for {
+		var tpl nucleiTemplate
+		if err := decoder.Decode(&tpl); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, err
+		}
+		fps, notes := convertNucleiTemplate(tpl)
+		db.Fingerprints = append(db.Fingerprints, fps...)
+		skipped = append(skipped, notes...)
+	}
+	return db, skipped, nil
+}
+
+// convertNucleiTemplate 转换单个模板
+func convertNucleiTemplate(tpl nucleiTemplate) ([]model.Fingerprint, []string) {
+	name := nucleiProductName(tpl)
+	skip := func(reason string) []string {
+		return []string{fmt.Sprintf("%s [%s]: %s", name, tpl.ID, reason)}
+	}
+	skipMatcher := func(m nucleiMatcher, reason string) []string {
+		if m.Name == "" {
+			return skip(reason)
+		}
+		return []string{fmt.Sprintf("%s [%s/%s]: %s", name, tpl.ID, m.Name, reason)}
+	}
+
+	requests := append(tpl.HTTP, tpl.Requests...)
+	if len(requests) != 1 {
+		return nil, skip("仅支持单个请求")
+	}
+	req := requests[0]
+	if len(req.Raw) > 0 {
+		return nil, skip("不支持raw请求")
+	}
+	var paths []string
+	for _, path := range req.Path {
+		path = strings.TrimPrefix(path, "{{BaseURL}}")
+		if strings.Contains(path, "{{") {
+			return nil, skip("路径中包含不支持的变量 " + path)
+		}
+		if path == "/" {
+			path = ""
+		}
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
+	var fps []model.Fingerprint
+	var notes []string
+	or := strings.ToLower(req.MatchersCondition) != "and"
+
+	// 条件为or且匹配器带名称时，每个匹配器对应一个独立的技术
+	var parts []string
+	for _, m := range req.Matchers {
+		expr, err := convertNucleiMatcher(m)
+		if err != nil {
+			if !or {
+				return nil, skip(err.Error())
+			}
+			notes = append(notes, skipMatcher(m, err.Error())...)
+			continue
+		}
+		if or && m.Name != "" {
+			fps = append(fps, model.Fingerprint{CMS: m.Name, Method: methodExpr, Expr: expr})
+			continue
+		}
+		parts = append(parts, expr)
+	}
+
+	if len(parts) > 0 {
+		op := "&&"
+		if or {
+			op = "||"
+		}
+		fps = append(fps, model.Fingerprint{CMS: name, Method: methodExpr, Expr: exprJoin(op, parts)})
+	}
+	if len(fps) == 0 && len(notes) == 0 {
+		return nil, skip("没有可用的匹配器")
+	}
+	return nucleiProbeRules(fps, req, paths), notes
+}
+
+// nucleiProbeRules 为每个请求路径生成规则，非首页路径或自定义请求转换为主动探测规则
+func nucleiProbeRules(fps []model.Fingerprint, req nucleiRequest, paths []string) []model.Fingerprint {
+	method := strings.ToUpper(req.Method)
+	if method == "GET" {
+		method = ""
+	}
+
+	var rules []model.Fingerprint
+	for _, path := range paths {
+		for _, fp := range fps {
+			fp.Path = path
+			fp.RequestMethod = method
+			fp.RequestHeaders = req.Headers
+			fp.RequestBody = req.Body
+			rules = append(rules, fp)
+		}
+	}
+	return rules
+}
+
+// nucleiProductName 从模板名称中去掉 "Detection" 等后缀
+func nucleiProductName(tpl nucleiTemplate) string {
+	name := strings.TrimSpace(tpl.Info.Name)
+	for _, suffix := range []string{" - Detect", " Detection", " Detect", " - Detection"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	if name == "" {
+		name = tpl.ID
+	}
+	return name
+}
+
+// convertNucleiMatcher 将匹配器转换为表达式
+func convertNucleiMatcher(m nucleiMatcher) (string, error) {
+	var locations []string
+	switch strings.ToLower(m.Part) {
+	case "", "body":
+		locations = []string{locationBody}
+	case "header":
+		locations = []string{locationHeader}
+	case "all", "response":
+		locations = []string{locationHeader, locationBody}
+	default:
+		if m.Type != "status" {
+			return "", fmt.Errorf("不支持的匹配部分 %q", m.Part)
+		}
+	}
+
+	var conds []string
+	switch m.Type {
+	case "word":
+		for _, word := range m.Words {
+			conds = append(conds, nucleiCondition(locations, word, m.CaseInsensitive, false))
+		}
+	case "regex":
+		for _, pattern := range m.Regex {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return "", fmt.Errorf("正则无法转换 %q", pattern)
+			}
+			conds = append(conds, nucleiCondition(locations, pattern, m.CaseInsensitive, true))
+		}
+	case "status":
+		for _, status := range m.Status {
+			conds = append(conds, exprCondition(locationStatus, "==", strconv.Itoa(status)))
+		}
+		m.Condition = "or"
+	default:
+		return "", fmt.Errorf("不支持的匹配器类型 %q", m.Type)
+	}
+	if len(conds) == 0 {
+		return "", fmt.Errorf("匹配器为空")
+	}
+
+	op := "||"
+	if strings.ToLower(m.Condition) == "and" {
+		op = "&&"
+	}
+	expr := exprJoin(op, conds)
+	if m.Negative {
+		expr = "!(" + expr + ")"
+	}
+	return expr, nil
+}
+
+// nucleiCondition 生成在任一位置上匹配的条件
+func nucleiCondition(locations []string, value string, caseInsensitive, regex bool) string {
+	op := "="
+	if regex || caseInsensitive {
+		if !regex {
+			value = regexp.QuoteMeta(value)
+		}
+		if caseInsensitive {
+			value = "(?i)" + value
+		}
+		op = "~="
+	}
+
+	var conds []string
+	for _, location := range locations {
+		conds = append(conds, exprCondition(location, op, value))
+	}
+	return exprJoin("||", conds)
+}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		data     string
+		want     string
+	}{
+		{"native", "finger.json", `{"fingerprint":[]}`, FormatNative},
+		{"wappalyzer", "technologies.json", `{"WordPress":{"cats":[1],"html":"wp-content"}}`, FormatWappalyzer},
+		{"fingerprinthub json", "web_fingerprint_v3.json", `[{"name":"x","path":"/","request_method":"get","keyword":["a"]}]`, FormatFingerprintHub},
+		{"tidefinger", "cms.json", `[{"cms_name":"x","keys":"a"}]`, FormatTideFinger},
+		{"goby", "goby.json", `[{"name":"x","rule":"body=\"a\""}]`, FormatGoby},
+		{"nuclei", "tech.yaml", "id: x\ninfo:\n  name: X\n", FormatNuclei},
+		{"fingerprinthub yaml", "web_fingerprint.yml", "- name: x\n  path: /\n  keyword:\n    - a\n", FormatFingerprintHub},
+		{"unknown yaml", "other.yaml", "foo: bar\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectFormat(tt.filename, []byte(tt.data)); got != tt.want {
+				t.Errorf("DetectFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertFingerprintHubYAML(t *testing.T) {
+	data := []byte(`
+- name: Jenkins
+  path: /
+  request_method: get
+  status_code: 0
+  headers:
+    X-Jenkins: ""
+  keyword:
+    - Dashboard [Jenkins]
+- name: Jenkins Login
+  path: /login
+  request_method: get
+  keyword:
+    - j_username
+`)
+	db, skipped, err := ConvertFingerprintHub(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 0 || len(db.Fingerprints) != 2 {
+		t.Fatalf("got %d fingerprints, skipped %v", len(db.Fingerprints), skipped)
+	}
+	if got := db.Fingerprints[0].Expr; got != `header:X-Jenkins="" && body="Dashboard [Jenkins]"` {
+		t.Errorf("expr = %s", got)
+	}
+	if got := db.Fingerprints[1]; got.Path != "/login" || got.Keywords[0] != "j_username" {
+		t.Errorf("probe rule = %+v", got)
+	}
+}
+
+func TestConvertersIdentify(t *testing.T) {
+	type sample struct {
+		headers map[string][]string
+		body    string
+	}
+	tests := []struct {
+		format string
+		data   string
+		hit    sample
+		miss   sample
+		want   []string
+	}{
+		{
+			FormatWappalyzer,
+			`{"WordPress": {"html": "<link[^>]+/wp-content/", "headers": {"X-Pingback": "/xmlrpc\\.php"}}}`,
+			sample{nil, `<link rel="stylesheet" href="/wp-content/themes/a.css">`},
+			sample{nil, `<p>/wp-content/</p>`},
+			[]string{"WordPress"},
+		},
+		{
+			FormatFingerprintHub,
+			`[{"name": "jenkins", "path": "/", "request_method": "get", "request_headers": {}, "request_data": "",
+			  "status_code": 0, "headers": {"X-Jenkins": ""}, "keyword": ["Dashboard [Jenkins]"], "favicon_hash": []}]`,
+			sample{map[string][]string{"X-Jenkins": {"2.426"}}, `<title>Dashboard [Jenkins]</title>`},
+			sample{nil, `<title>Dashboard [Jenkins]</title>`},
+			[]string{"jenkins"},
+		},
+		{
+			FormatTideFinger,
+			`[{"cms_name": "ThinkPHP", "path": "/", "match_pattern": "thinkphp", "options": "keyword"},
+			  {"name": "Shiro", "keys": "header=\"rememberMe=deleteMe\""}]`,
+			sample{map[string][]string{"Set-Cookie": {"rememberMe=deleteMe; Path=/"}}, `<a href="http://www.thinkphp.cn">thinkphp</a>`},
+			sample{nil, `ThinkPHP`},
+			[]string{"Shiro", "ThinkPHP"},
+		},
+		{
+			FormatGoby,
+			`[{"product": "Tomcat", "rules": [[{"match": "title_contains", "content": "Apache Tomcat"}], [{"match": "server_contains", "content": "Coyote"}]]}]`,
+			sample{map[string][]string{"Server": {"Apache-Coyote/1.1"}}, `<title>Error</title>`},
+			sample{map[string][]string{"Server": {"nginx"}}, `<p>Apache Tomcat</p>`},
+			[]string{"Tomcat"},
+		},
+		{
+			FormatNuclei,
+			`
+id: nginx-detect
+info:
+  name: Nginx
+http:
+  - method: GET
+    path:
+      - "{{BaseURL}}"
+    matchers-condition: and
+    matchers:
+      - type: word
+        part: header
+        words:
+          - "nginx"
+      - type: status
+        status:
+          - 200
+`,
+			sample{map[string][]string{"Server": {"nginx/1.24"}}, `ok`},
+			sample{map[string][]string{"Server": {"Apache"}}, `nginx`},
+			[]string{"Nginx"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			db, notes, err := ConvertFingerprints(tt.format, []byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(notes) > 0 {
+				t.Errorf("unexpected notes: %v", notes)
+			}
+			s := newTestScanner(t, db)
+
+			got := identify(s, newTestResponse(200, tt.hit.headers, tt.hit.body))
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("identify(hit) = %v, want %v", got, tt.want)
+			}
+			if got := identify(s, newTestResponse(200, tt.miss.headers, tt.miss.body)); len(got) > 0 {
+				t.Errorf("identify(miss) = %v, want none", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"strings"
+)
+
+// tideFingerRow TideFinger指纹表导出的一行
+// cms表使用 cms_name/path/match_pattern/options，fofa表使用 name/keys
+type tideFingerRow struct {
+	CMSName      string `json:"cms_name"`
+	Path         string `json:"path"`
+	MatchPattern string `json:"match_pattern"`
+	Options      string `json:"options"`
+	Name         string `json:"name"`
+	Keys         string `json:"keys"`
+}
+
+// ConvertTideFinger 转换TideFinger的cms和fofa指纹表导出(JSON数组)，非首页路径转换为主动探测规则
+func ConvertTideFinger(data []byte) (*model.FingerprintDB, []string, error) {
+	var rows []tideFingerRow
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, nil, err
+	}
+
+	db := &model.FingerprintDB{}
+	var skipped []string
+	for _, row := range rows {
+		if row.Keys != "" {
+			expr, err := convertFOFAQuery(row.Keys)
+			if err != nil {
+				skipped = append(skipped, fmt.Sprintf("%s [keys]: %v", row.Name, err))
+				continue
+			}
+			db.Fingerprints = append(db.Fingerprints, model.Fingerprint{CMS: row.Name, Method: methodExpr, Expr: expr})
+			continue
+		}
+
+		fp, reason := convertTideFingerCMS(row)
+		if reason != "" {
+			skipped = append(skipped, fmt.Sprintf("%s [%s]: %s", row.CMSName, row.Path, reason))
+			continue
+		}
+		db.Fingerprints = append(db.Fingerprints, fp)
+	}
+	return db, skipped, nil
+}
+
+// convertTideFingerCMS 转换cms表的一行，无法表示时返回原因
+func convertTideFingerCMS(row tideFingerRow) (model.Fingerprint, string) {
+	if row.MatchPattern == "" {
+		return model.Fingerprint{}, "规则为空"
+	}
+
+	fp := model.Fingerprint{CMS: row.CMSName, Location: locationBody, Keywords: []string{row.MatchPattern}}
+	if row.Path != "/" {
+		fp.Path = row.Path
+	}
+	switch strings.ToLower(row.Options) {
+	case "keyword", "":
+		fp.Method = methodKeyword
+	case "regx", "regex":
+		fp.Method = methodRegular
+	case "md5":
+		fp.Method = methodKeyword
+		fp.Location = locationBodyHash
+		fp.Keywords = []string{strings.ToLower(row.MatchPattern)}
+	default:
+		return model.Fingerprint{}, "不支持的匹配方式 " + row.Options
+	}
+	return fp, ""
+}
@@ -0,0 +1,362 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/andybalholm/cascadia"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"net/textproto"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// wappalyzerFile Wappalyzer technologies.json 文件结构
+type wappalyzerFile struct {
+	Technologies map[string]wappalyzerTech     `json:"technologies"`
+	Categories   map[string]wappalyzerCategory `json:"categories"`
+}
+
+// wappalyzerCategory Wappalyzer 分类定义，priority越小越重要
+type wappalyzerCategory struct {
+	Name     string `json:"name"`
+	Priority int    `json:"priority"`
+}
+
+// wappalyzerTech Wappalyzer 技术定义，仅包含可以转换的字段
+type wappalyzerTech struct {
+	Cats      []int                     `json:"cats"`
+	CPE       string                    `json:"cpe"`
+	Headers   map[string]string         `json:"headers"`
+	Cookies   map[string]string         `json:"cookies"`
+	Meta      map[string]wappalyzerList `json:"meta"`
+	ScriptSrc wappalyzerList            `json:"scriptSrc"`
+	Scripts   wappalyzerList            `json:"scripts"`
+	HTML      wappalyzerList            `json:"html"`
+	Text      wappalyzerList            `json:"text"`
+	Implies   wappalyzerList            `json:"implies"`
+	Excludes  wappalyzerList            `json:"excludes"`
+
+	DOM json.RawMessage `json:"dom"`
+
+	// 以下字段需要浏览器环境或额外请求，无法转换
+	URL  wappalyzerList         `json:"url"`
+	JS   map[string]string      `json:"js"`
+	CSS  wappalyzerList         `json:"css"`
+	XHR  wappalyzerList         `json:"xhr"`
+	DNS  map[string]interface{} `json:"dns"`
+	Cert wappalyzerList         `json:"certIssuer"`
+}
+
+// wappalyzerList 兼容字符串和字符串数组两种写法
+type wappalyzerList []string
+
+// UnmarshalJSON 解析字符串或字符串数组
+func (l *wappalyzerList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = wappalyzerList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// wappalyzerPattern 解析后的Wappalyzer模式
+type wappalyzerPattern struct {
+	regex      string // 正则部分
+	version    string // \;version: 后的版本模板
+	confidence int    // \;confidence: 后的置信度，0表示未设置
+}
+
+// parseWappalyzerPattern 拆分 "regex\;version:\1\;confidence:50" 形式的模式
+func parseWappalyzerPattern(raw string) wappalyzerPattern {
+	parts := strings.Split(raw, `\;`)
+	p := wappalyzerPattern{regex: parts[0]}
+	for _, part := range parts[1:] {
+		switch {
+		case strings.HasPrefix(part, "version:"):
+			p.version = strings.TrimPrefix(part, "version:")
+		case strings.HasPrefix(part, "confidence:"):
+			p.confidence, _ = strconv.Atoi(strings.TrimPrefix(part, "confidence:"))
+		}
+	}
+	return p
+}
+
+// isWappalyzerData 判断数据是否为Wappalyzer格式
+func isWappalyzerData(top map[string]json.RawMessage) bool {
+	if _, ok := top["technologies"]; ok {
+		return true
+	}
+	for _, raw := range top {
+		var tech map[string]json.RawMessage
+		if json.Unmarshal(raw, &tech) != nil {
+			return false
+		}
+		for _, key := range []string{"cats", "html", "headers", "scriptSrc", "meta", "implies", "website"} {
+			if _, ok := tech[key]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ConvertWappalyzer 将Wappalyzer技术定义转换为指纹库，返回跳过的规则和未能提取版本的规则说明
+// 同时支持完整的technologies.json和按字母拆分的 technologies/*.json
+func ConvertWappalyzer(data []byte) (*model.FingerprintDB, []ConvertNote, error) {
+	var file wappalyzerFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, nil, err
+	}
+	if file.Technologies == nil {
+		if err := json.Unmarshal(data, &file.Technologies); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	names := make([]string, 0, len(file.Technologies))
+	for name := range file.Technologies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	db := &model.FingerprintDB{}
+	var notes []ConvertNote
+	for _, name := range names {
+		fps, techNotes := convertWappalyzerTech(name, file.Technologies[name], file.Categories)
+		db.Fingerprints = append(db.Fingerprints, fps...)
+		notes = append(notes, techNotes...)
+	}
+	return db, notes, nil
+}
+
+// convertWappalyzerTech 转换单个技术定义
+func convertWappalyzerTech(name string, tech wappalyzerTech, categories map[string]wappalyzerCategory) ([]model.Fingerprint, []ConvertNote) {
+	c := &wappalyzerConverter{name: name, meta: wappalyzerMetadata(name, tech, categories)}
+
+	// 响应头和Cookie的每个取值占一行，^ 和 $ 按行锚定
+	for _, header := range sortedKeys(tech.Headers) {
+		p := parseWappalyzerPattern(tech.Headers[header])
+		c.add(headerLocationPrefix+textproto.CanonicalMIMEHeaderKey(header), "headers."+header, p, "(?m)"+p.regex)
+	}
+
+	for _, cookie := range sortedKeys(tech.Cookies) {
+		p := parseWappalyzerPattern(tech.Cookies[cookie])
+		prefix := `(?m)^` + regexp.QuoteMeta(cookie) + `=`
+		c.add(locationCookie, "cookies."+cookie, p, prefix+wrapWappalyzerRegex(p.regex, `.*`, `$`))
+	}
+
+	// meta和scriptSrc同样按行匹配提取出的meta标签和脚本地址
+	for _, meta := range sortedKeys(tech.Meta) {
+		for _, raw := range tech.Meta[meta] {
+			p := parseWappalyzerPattern(raw)
+			prefix := `(?m)^` + regexp.QuoteMeta(meta) + `: `
+			c.add(locationMeta, "meta."+meta, p, prefix+wrapWappalyzerRegex(p.regex, `.*`, `$`))
+		}
+	}
+
+	for _, raw := range tech.ScriptSrc {
+		p := parseWappalyzerPattern(raw)
+		c.add(locationScript, "scriptSrc", p, "(?m)"+p.regex)
+	}
+
+	for _, field := range []struct {
+		name     string
+		patterns wappalyzerList
+	}{{"html", tech.HTML}, {"scripts", tech.Scripts}, {"text", tech.Text}} {
+		for _, raw := range field.patterns {
+			p := parseWappalyzerPattern(raw)
+			c.add(locationBody, field.name, p, p.regex)
+		}
+	}
+
+	c.addDOM(tech.DOM)
+
+	for field, unsupported := range map[string]bool{
+		"url": len(tech.URL) > 0, "js": len(tech.JS) > 0,
+		"css": len(tech.CSS) > 0, "xhr": len(tech.XHR) > 0, "dns": len(tech.DNS) > 0,
+		"certIssuer": len(tech.Cert) > 0,
+	} {
+		if unsupported {
+			c.skip(field, "不支持的匹配位置")
+		}
+	}
+	sort.Slice(c.notes, func(i, j int) bool {
+		return c.notes[i].Message < c.notes[j].Message
+	})
+	return c.fingerprints, c.notes
+}
+
+// wappalyzerConverter 单个技术的转换状态
+type wappalyzerConverter struct {
+	name         string
+	meta         model.Fingerprint // 各规则共用的元数据
+	fingerprints []model.Fingerprint
+	notes        []ConvertNote
+}
+
+// add 校验正则并添加一条regular指纹
+func (c *wappalyzerConverter) add(location, field string, p wappalyzerPattern, pattern string) {
+	pattern = "(?i)" + pattern
+	if _, err := regexp.Compile(pattern); err != nil {
+		c.skip(field, fmt.Sprintf("正则无法转换 %q", p.regex))
+		return
+	}
+	fp := c.meta
+	fp.Method = methodRegular
+	fp.Location = location
+	fp.Keywords = []string{pattern}
+	if p.confidence > 0 && p.confidence < maxConfidence {
+		fp.Weight = p.confidence
+	}
+	if p.version != "" {
+		if group, ok := wappalyzerVersionGroup(p.version); ok {
+			fp.VersionGroup = group
+		} else {
+			c.note(field, fmt.Sprintf("版本模板无法转换 %q", p.version), true)
+		}
+	}
+	c.fingerprints = append(c.fingerprints, fp)
+}
+
+// addDOM 将dom中的CSS选择器转换为selector指纹
+// 仅支持选择器字符串、数组和只检查exists的对象，属性、文本等条件无法转换
+func (c *wappalyzerConverter) addDOM(raw json.RawMessage) {
+	if len(raw) == 0 {
+		return
+	}
+	var selectors wappalyzerList
+	if err := json.Unmarshal(raw, &selectors); err == nil {
+		for _, selector := range selectors {
+			c.addSelector(selector)
+		}
+		return
+	}
+
+	var objects map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &objects); err != nil {
+		c.skip("dom", "无法解析")
+		return
+	}
+	for _, selector := range sortedKeys(objects) {
+		if _, ok := objects[selector]["exists"]; ok && len(objects[selector]) == 1 {
+			c.addSelector(selector)
+		} else {
+			c.skip("dom."+selector, "只支持exists条件")
+		}
+	}
+}
+
+// addSelector 校验选择器并添加一条selector指纹
+func (c *wappalyzerConverter) addSelector(selector string) {
+	if _, err := cascadia.Compile(selector); err != nil {
+		c.skip("dom", fmt.Sprintf("选择器无法转换 %q", selector))
+		return
+	}
+	fp := c.meta
+	fp.Method = methodSelector
+	fp.Location = locationBody
+	fp.Keywords = []string{selector}
+	c.fingerprints = append(c.fingerprints, fp)
+}
+
+// wappalyzerMetadata 生成技术的元数据，第一个分类作为category，全部分类作为tags
+// 分类定义缺失时使用分类编号
+func wappalyzerMetadata(name string, tech wappalyzerTech, categories map[string]wappalyzerCategory) model.Fingerprint {
+	fp := model.Fingerprint{
+		CMS:        name,
+		Product:    name,
+		CPE:        tech.CPE,
+		Importance: ImportanceInfo,
+		Implies:    wappalyzerNames(tech.Implies),
+		Excludes:   wappalyzerNames(tech.Excludes),
+	}
+	for i, id := range tech.Cats {
+		category, ok := categories[strconv.Itoa(id)]
+		if !ok {
+			category = wappalyzerCategory{Name: strconv.Itoa(id)}
+		}
+		if i == 0 {
+			fp.Category = category.Name
+		}
+		fp.Tags = append(fp.Tags, category.Name)
+
+		if importance := wappalyzerImportance(category.Priority); ImportanceLevel(importance) > ImportanceLevel(fp.Importance) {
+			fp.Importance = importance
+		}
+	}
+	return fp
+}
+
+// wappalyzerNames 去掉 implies、excludes 中 \;confidence: 等附加参数，只保留技术名称
+func wappalyzerNames(list wappalyzerList) []string {
+	var names []string
+	for _, raw := range list {
+		names = append(names, parseWappalyzerPattern(raw).regex)
+	}
+	return names
+}
+
+// wappalyzerImportance 将分类优先级映射为重要程度
+func wappalyzerImportance(priority int) string {
+	switch {
+	case priority <= 0:
+		return ImportanceInfo
+	case priority <= 2:
+		return ImportanceMedium
+	case priority <= 5:
+		return ImportanceLow
+	}
+	return ImportanceInfo
+}
+
+// wappalyzerVersionGroup 解析 \1 形式的版本模板，三元表达式等复杂模板无法转换
+func wappalyzerVersionGroup(template string) (int, bool) {
+	if !strings.HasPrefix(template, `\`) {
+		return 0, false
+	}
+	group, err := strconv.Atoi(template[1:])
+	return group, err == nil && group > 0
+}
+
+// skip 记录无法表示的规则
+func (c *wappalyzerConverter) skip(field, reason string) {
+	c.note(field, reason, false)
+}
+
+// note 记录转换说明，partial表示规则已转换但未能提取版本
+func (c *wappalyzerConverter) note(field, reason string, partial bool) {
+	c.notes = append(c.notes, ConvertNote{Message: fmt.Sprintf("%s [%s]: %s", c.name, field, reason), Partial: partial})
+}
+
+// wrapWappalyzerRegex 将锚定于取值的正则嵌入到上下文中
+// ^ 表示取值开头，$ 表示取值结尾，未锚定时允许取值中任意前缀
+func wrapWappalyzerRegex(regex, anyPrefix, end string) string {
+	prefix, suffix := anyPrefix, ""
+	if strings.HasPrefix(regex, "^") {
+		regex = strings.TrimPrefix(regex, "^")
+		prefix = ""
+	}
+	if strings.HasSuffix(regex, "$") && !strings.HasSuffix(regex, `\$`) {
+		regex = strings.TrimSuffix(regex, "$")
+		suffix = end
+	}
+	return prefix + "(?:" + regex + ")" + suffix
+}
+
+// sortedKeys 返回排序后的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
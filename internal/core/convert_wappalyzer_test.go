@@ -0,0 +1,55 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertWappalyzerHeaderPresence(t *testing.T) {
+	data := []byte(`{"Drupal": {"headers": {"X-Drupal-Cache": ""}}}`)
+	db, _, err := ConvertWappalyzer(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestScanner(t, db)
+
+	tests := []struct {
+		name    string
+		headers map[string][]string
+		want    []string
+	}{
+		{"header present", map[string][]string{"X-Drupal-Cache": {"HIT"}}, []string{"Drupal"}},
+		{"header present with empty value", map[string][]string{"X-Drupal-Cache": {""}}, []string{"Drupal"}},
+		{"header missing", map[string][]string{"Server": {"nginx"}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := identify(s, newTestResponse(200, tt.headers, "<html></html>"))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("identify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertWappalyzerVersionNotes(t *testing.T) {
+	data := []byte(`{
+		"Nginx": {"headers": {"Server": "nginx(?:/([\\d.]+))?\\;version:\\1"}},
+		"Foo": {"html": ["foo-(\\d)\\;version:\\1?v2:v1"], "dns": {"TXT": ["foo"]}}
+	}`)
+	db, notes, err := ConvertWappalyzer(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(db.Fingerprints) != 2 {
+		t.Fatalf("got %d fingerprints, want 2", len(db.Fingerprints))
+	}
+
+	want := []ConvertNote{
+		{Message: `Foo [dns]: 不支持的匹配位置`},
+		{Message: `Foo [html]: 版本模板无法转换 "\\1?v2:v1"`, Partial: true},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("notes = %#v, want %#v", notes, want)
+	}
+}
@@ -0,0 +1,575 @@
+package core
+
+import (
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 支持的匹配方法
+const (
+	methodKeyword     = "keyword"
+	methodRegular     = "regular"
+	methodFaviconHash = "faviconhash"
+	methodExpr        = "expr"
+	methodSelector    = "selector"
+)
+
+// 支持的匹配位置
+const (
+	locationBody     = "body"
+	locationHeader   = "header"
+	locationTitle    = "title"
+	locationCookie   = "cookie"    // Set-Cookie的名称和值，每行一个 name=value
+	locationStatus   = "status"    // 状态码
+	locationURL      = "url"       // 跟随跳转后的最终URL
+	locationRedirect = "location"  // 跳转目标，每行一个
+	locationBodyHash = "body_hash" // 响应体的mmh3或md5
+	locationMeta     = "meta"      // meta标签，每行一个 name: content
+	locationScript   = "script"    // script标签的src，每行一个
+	locationLink     = "link"      // link标签的href，每行一个
+	locationGlobal   = "global"    // 内联脚本中赋值的全局变量名，每行一个
+	locationForm     = "form"      // 表单的action，每行一个
+	locationJS       = "js"        // 抓取的同源脚本内容
+
+	// 以下位置目前仅可用于表达式规则
+	locationIconHash = "icon_hash" // 各图标的mmh3、md5和sha256
+
+	// headerLocationPrefix 单个响应头的位置前缀，如 header:X-Jenkins
+	headerLocationPrefix = "header:"
+
+	// locationHeaderJSON header_format为json的规则实际读取的旧版JSON头视图，不可在规则中直接使用
+	locationHeaderJSON = "header_json"
+)
+
+// header位置支持的格式
+const (
+	headerFormatRaw  = "raw"
+	headerFormatJSON = "json"
+)
+
+// isMatchLocation 判断规则是否可以使用该位置
+func isMatchLocation(location string) bool {
+	switch location {
+	case locationBody, locationHeader, locationTitle, locationCookie,
+		locationStatus, locationURL, locationRedirect, locationBodyHash,
+		locationMeta, locationScript, locationLink, locationGlobal, locationForm, locationJS:
+		return true
+	}
+	return strings.HasPrefix(location, headerLocationPrefix) && len(location) > len(headerLocationPrefix)
+}
+
+// isExactLocation 判断位置是否按取值完全相等匹配，而非包含关系
+func isExactLocation(location string) bool {
+	switch location {
+	case locationStatus, locationBodyHash, locationIconHash:
+		return true
+	}
+	return false
+}
+
+// Engine 预编译的指纹匹配引擎
+type Engine struct {
+	rules     []*rule
+	groups    []*ruleGroup
+	keywords  map[string]*keywordIndex // 按位置划分的关键字自动机
+	probes    []*probe                 // 主动探测请求
+	relations map[string]*techRelation // 技术名称 -> 推断和排除关系
+	Errors    []error                  // 编译失败而被跳过的指纹
+}
+
+// rule 编译后的单条指纹规则
+type rule struct {
+	index      int               // 在指纹库中的序号
+	id         string            // 规则标识
+	fp         model.Fingerprint // 原始指纹
+	location   string            // 实际读取内容的位置
+	patterns   []*regexp.Regexp  // 预编译的正则
+	selectors  []goquery.Matcher // 预编译的CSS选择器
+	expr       exprNode          // 解析后的表达式
+	version    *regexp.Regexp    // 单独的版本正则
+	statusOnly bool              // 只匹配状态码
+	keywordIDs []int             // 关键字在所属位置自动机中的序号
+}
+
+// keywordIndex 某一位置上所有keyword规则的关键字自动机
+type keywordIndex struct {
+	matcher  *utils.AhoCorasick
+	keywords []string
+	rules    [][]*rule // 关键字序号 -> 包含该关键字的规则
+	always   []*rule   // 只含空关键字、必然命中的规则
+}
+
+// ruleGroup 按位置和方法分组的规则
+type ruleGroup struct {
+	location string
+	method   string
+	rules    []*rule
+}
+
+// NewEngine 编译指纹库，无效的指纹记录在Errors中并被跳过
+func NewEngine(db *model.FingerprintDB) *Engine {
+	e := &Engine{relations: make(map[string]*techRelation)}
+	groups := make(map[string]*ruleGroup)
+	probes := make(map[string]*probe)
+
+	for i, fp := range db.Fingerprints {
+		r, err := compileRule(i, fp)
+		if err != nil {
+			e.Errors = append(e.Errors, fmt.Errorf("指纹 #%d (%s): %v", i, fp.CMS, err))
+			continue
+		}
+		e.rules = append(e.rules, r)
+		e.addRelation(fp)
+
+		// 主动规则只匹配探测请求的响应
+		if isActiveRule(fp) {
+			e.addProbeRule(r, probes)
+			continue
+		}
+
+		key := r.location + "\x00" + fp.Method
+		group, ok := groups[key]
+		if !ok {
+			group = &ruleGroup{location: r.location, method: fp.Method}
+			groups[key] = group
+			e.groups = append(e.groups, group)
+		}
+		group.rules = append(group.rules, r)
+	}
+
+	e.buildKeywordIndex()
+	return e
+}
+
+// buildKeywordIndex 为每个位置的keyword规则构建Aho-Corasick自动机
+func (e *Engine) buildKeywordIndex() {
+	e.keywords = make(map[string]*keywordIndex)
+	ids := make(map[string]map[string]int)
+
+	for _, group := range e.groups {
+		// 完全相等匹配的位置逐条比较，不建立自动机
+		if group.method != methodKeyword || isExactLocation(group.location) {
+			continue
+		}
+		idx, ok := e.keywords[group.location]
+		if !ok {
+			idx = &keywordIndex{}
+			e.keywords[group.location] = idx
+			ids[group.location] = make(map[string]int)
+		}
+
+		for _, r := range group.rules {
+			for _, keyword := range utils.RemoveDuplicates(r.fp.Keywords) {
+				id, ok := ids[group.location][keyword]
+				if !ok {
+					id = len(idx.keywords)
+					ids[group.location][keyword] = id
+					idx.keywords = append(idx.keywords, keyword)
+					idx.rules = append(idx.rules, nil)
+				}
+				r.keywordIDs = append(r.keywordIDs, id)
+				idx.rules[id] = append(idx.rules[id], r)
+			}
+			if len(r.keywordIDs) == 0 {
+				idx.always = append(idx.always, r)
+			}
+		}
+	}
+
+	for _, idx := range e.keywords {
+		idx.matcher = utils.NewAhoCorasick(idx.keywords)
+	}
+}
+
+// candidates 返回关键字全部命中的keyword规则
+func (idx *keywordIndex) candidates(hits map[int]int) []*rule {
+	matched := append([]*rule(nil), idx.always...)
+	counts := make(map[*rule]int)
+	for id := range hits {
+		for _, r := range idx.rules[id] {
+			counts[r]++
+			if counts[r] == len(r.keywordIDs) {
+				matched = append(matched, r)
+			}
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].index < matched[j].index
+	})
+	return matched
+}
+
+// compileRule 校验并编译单条指纹
+func compileRule(index int, fp model.Fingerprint) (*rule, error) {
+	r, err := compileMatcher(index, fp)
+	if err != nil {
+		return nil, err
+	}
+	r.id = ruleID(fp, index)
+	if err := r.compileHeaderFormat(); err != nil {
+		return nil, err
+	}
+	if err := r.compileVersion(); err != nil {
+		return nil, err
+	}
+	if err := validateHashAlgorithm(fp); err != nil {
+		return nil, err
+	}
+	if err := validateMetadata(fp); err != nil {
+		return nil, err
+	}
+	if err := validateProbe(fp); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ruleID 返回规则标识，未设置时使用 CMS#序号
+func ruleID(fp model.Fingerprint, index int) string {
+	if fp.ID != "" {
+		return fp.ID
+	}
+	return fmt.Sprintf("%s#%d", fp.CMS, index)
+}
+
+// compileMatcher 校验并编译指纹的匹配条件
+func compileMatcher(index int, fp model.Fingerprint) (*rule, error) {
+	if fp.Method == methodExpr {
+		expr, err := parseExpr(fp.Expr)
+		if err != nil {
+			return nil, err
+		}
+		return &rule{index: index, fp: fp, expr: expr}, nil
+	}
+
+	// 选择器作用于响应体解析出的DOM，位置可以省略
+	if fp.Method == methodSelector {
+		return compileSelectors(index, fp)
+	}
+
+	// 只检查状态码的规则，方法和位置可以省略，填写时仍需有效
+	if len(fp.Keywords) == 0 && fp.StatusCode != 0 {
+		switch fp.Method {
+		case "", methodKeyword, methodRegular, methodFaviconHash:
+		default:
+			return nil, fmt.Errorf("未知的匹配方法 %q", fp.Method)
+		}
+		if fp.Location != "" && !isMatchLocation(fp.Location) {
+			return nil, fmt.Errorf("未知的匹配位置 %q", fp.Location)
+		}
+		return &rule{index: index, fp: fp, statusOnly: true}, nil
+	}
+
+	if !isMatchLocation(fp.Location) {
+		return nil, fmt.Errorf("未知的匹配位置 %q", fp.Location)
+	}
+	if len(fp.Keywords) == 0 {
+		return nil, fmt.Errorf("关键字列表为空")
+	}
+
+	r := &rule{index: index, fp: fp}
+	switch fp.Method {
+	case methodKeyword:
+	case methodRegular:
+		for _, pattern := range fp.Keywords {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("无效的正则 %q: %v", pattern, err)
+			}
+			r.patterns = append(r.patterns, re)
+		}
+	case methodFaviconHash:
+		if fp.Location != locationBody {
+			return nil, fmt.Errorf("faviconhash 只能用于 body 位置")
+		}
+	default:
+		return nil, fmt.Errorf("未知的匹配方法 %q", fp.Method)
+	}
+	return r, nil
+}
+
+// compileSelectors 校验并编译selector规则
+func compileSelectors(index int, fp model.Fingerprint) (*rule, error) {
+	if fp.Location != "" && fp.Location != locationBody {
+		return nil, fmt.Errorf("selector 只能用于 body 位置")
+	}
+	if len(fp.Keywords) == 0 {
+		return nil, fmt.Errorf("选择器列表为空")
+	}
+
+	r := &rule{index: index, fp: fp}
+	for _, selector := range fp.Keywords {
+		matcher, err := cascadia.Compile(selector)
+		if err != nil {
+			return nil, fmt.Errorf("无效的选择器 %q: %v", selector, err)
+		}
+		r.selectors = append(r.selectors, matcher)
+	}
+	return r, nil
+}
+
+// compileHeaderFormat 确定规则实际读取的位置，header_format为json时header改为读取旧版JSON视图
+func (r *rule) compileHeaderFormat() error {
+	r.location = r.fp.Location
+	switch r.fp.HeaderFormat {
+	case "", headerFormatRaw:
+		return nil
+	case headerFormatJSON:
+	default:
+		return fmt.Errorf("未知的header_format %q", r.fp.HeaderFormat)
+	}
+
+	if r.expr != nil {
+		replaceExprLocation(r.expr, locationHeader, locationHeaderJSON)
+	} else if r.location == locationHeader {
+		r.location = locationHeaderJSON
+	}
+	return nil
+}
+
+// compileVersion 校验并编译版本提取配置
+func (r *rule) compileVersion() error {
+	if r.fp.VersionGroup > 0 {
+		if len(r.patterns) == 0 {
+			return fmt.Errorf("version_group 只能用于 regular 规则")
+		}
+		if r.fp.VersionGroup > r.patterns[0].NumSubexp() {
+			return fmt.Errorf("version_group %d 超出正则 %q 的捕获组数量", r.fp.VersionGroup, r.fp.Keywords[0])
+		}
+	}
+
+	if r.fp.VersionAttr != "" && r.selectors == nil {
+		return fmt.Errorf("version_attr 只能用于 selector 规则")
+	}
+
+	if r.fp.VersionRegex != "" {
+		re, err := regexp.Compile(r.fp.VersionRegex)
+		if err != nil {
+			return fmt.Errorf("无效的版本正则 %q: %v", r.fp.VersionRegex, err)
+		}
+		if re.NumSubexp() == 0 {
+			return fmt.Errorf("版本正则 %q 缺少捕获组", r.fp.VersionRegex)
+		}
+		r.version = re
+	}
+	return nil
+}
+
+// versionLocation 版本正则作用的位置，表达式规则默认使用body
+func (r *rule) versionLocation() string {
+	if r.fp.Method == methodExpr || r.fp.Method == methodSelector || r.location == "" {
+		return locationBody
+	}
+	return r.location
+}
+
+// Len 返回有效规则数量
+func (e *Engine) Len() int {
+	return len(e.rules)
+}
+
+// responseView 响应的匹配视图，各位置的内容每个响应只计算一次
+type responseView struct {
+	engine   *Engine
+	resp     *model.HTTPResponse
+	path     string                 // 主动探测的路径，首页响应为空
+	headers  map[string]string      // 各格式的响应头视图
+	hashes   []string               // 响应体的mmh3和md5
+	doc      *goquery.Document      // 响应体解析出的DOM
+	keywords map[string]map[int]int // 位置 -> 命中的关键字序号及偏移
+}
+
+// newResponseView 创建响应视图
+func newResponseView(engine *Engine, resp *model.HTTPResponse) *responseView {
+	return &responseView{
+		engine:   engine,
+		resp:     resp,
+		headers:  make(map[string]string),
+		keywords: make(map[string]map[int]int),
+	}
+}
+
+// keywordHits 单次扫描指定位置内容，返回命中的关键字
+func (v *responseView) keywordHits(location string) map[int]int {
+	if hits, ok := v.keywords[location]; ok {
+		return hits
+	}
+	var hits map[int]int
+	if idx := v.engine.keywords[location]; idx != nil {
+		if content, ok := v.content(location); ok {
+			hits = idx.matcher.FindAll(content)
+		}
+	}
+	v.keywords[location] = hits
+	return hits
+}
+
+// content 获取指定位置的内容
+func (v *responseView) content(location string) (string, bool) {
+	switch location {
+	case locationBody:
+		return v.resp.Body, true
+	case locationHeader, locationHeaderJSON:
+		header, ok := v.headers[location]
+		if !ok {
+			if location == locationHeader {
+				header = utils.HeadersToRaw(v.resp.Headers)
+			} else {
+				header = utils.HeadersToString(v.resp.Headers)
+			}
+			v.headers[location] = header
+		}
+		return header, true
+	case locationTitle:
+		return v.resp.Title, true
+	case locationCookie:
+		return strings.Join(cookieValues(v.resp.Headers), "\n"), true
+	case locationURL:
+		if v.resp.FinalURL != "" {
+			return v.resp.FinalURL, true
+		}
+		return v.resp.URL, true
+	case locationRedirect:
+		return strings.Join(v.redirects(), "\n"), true
+	case locationMeta:
+		return strings.Join(v.resp.Metas, "\n"), true
+	case locationScript:
+		return strings.Join(v.resp.Scripts, "\n"), true
+	case locationLink:
+		return strings.Join(v.resp.Links, "\n"), true
+	case locationGlobal:
+		return strings.Join(v.resp.Globals, "\n"), true
+	case locationForm:
+		return strings.Join(v.resp.FormActions, "\n"), true
+	case locationJS:
+		return v.resp.JS, true
+	case locationStatus, locationBodyHash, locationIconHash:
+		return strings.Join(v.values(location), "\n"), true
+	}
+	if name := strings.TrimPrefix(location, headerLocationPrefix); name != location && name != "" {
+		// 响应头不存在时不参与匹配，否则空关键字和空正则会在所有响应上命中
+		values := utils.HeaderValues(v.resp.Headers, name)
+		return strings.Join(values, "\n"), len(values) > 0
+	}
+	return "", false
+}
+
+// document 获取响应体解析出的DOM，优先使用extractPage的解析结果，每个响应只解析一次
+func (v *responseView) document() *goquery.Document {
+	if v.doc == nil {
+		v.doc, _ = parseDocument(v.resp.Body)
+	}
+	return v.doc
+}
+
+// values 获取完全相等匹配位置的全部取值
+func (v *responseView) values(location string) []string {
+	switch location {
+	case locationStatus:
+		return []string{strconv.Itoa(v.resp.StatusCode)}
+	case locationBodyHash:
+		if v.hashes == nil {
+			mmh3, md5sum := utils.BodyHashes([]byte(v.resp.Body))
+			v.hashes = []string{mmh3, md5sum}
+		}
+		return v.hashes
+	case locationIconHash:
+		return faviconValues(v.resp)
+	}
+	return nil
+}
+
+// redirects 获取跳转目标，包括未被跟随的Location响应头
+func (v *responseView) redirects() []string {
+	redirects := append([]string(nil), v.resp.Redirects...)
+	return append(redirects, utils.HeaderValues(v.resp.Headers, "Location")...)
+}
+
+// cookieValues 提取Set-Cookie中的 name=value，忽略Path等属性
+func cookieValues(headers map[string][]string) []string {
+	var cookies []string
+	for _, cookie := range utils.HeaderValues(headers, "Set-Cookie") {
+		if i := strings.IndexByte(cookie, ';'); i >= 0 {
+			cookie = cookie[:i]
+		}
+		cookies = append(cookies, strings.TrimSpace(cookie))
+	}
+	return cookies
+}
+
+// matchValues 检查每个关键字是否都与某个取值完全相等
+func matchValues(values, keywords []string) bool {
+	for _, keyword := range keywords {
+		if !containsValue(values, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchValuePatterns 检查每个正则是否都匹配某个取值
+func matchValuePatterns(values []string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if matchedValue(values, re) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchedValue 返回第一个匹配正则的取值序号，没有时返回-1
+func matchedValue(values []string, re *regexp.Regexp) int {
+	for i, v := range values {
+		if re.MatchString(v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// containsValue 判断取值列表中是否存在指定值
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKeywordIDs 检查规则的全部关键字是否都已命中
+func matchKeywordIDs(hits map[int]int, ids []int) bool {
+	for _, id := range ids {
+		if _, ok := hits[id]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSelectors 检查DOM中是否存在匹配每个选择器的元素
+func matchSelectors(doc *goquery.Document, selectors []goquery.Matcher) bool {
+	for _, selector := range selectors {
+		if doc.FindMatcher(selector).Length() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPatterns 检查内容是否匹配全部正则
+func matchPatterns(content string, patterns []*regexp.Regexp) bool {
+	for _, re := range patterns {
+		if !re.MatchString(content) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSelectorReusesPageDocument(t *testing.T) {
+	s := newTestScanner(t, &model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		{CMS: "Vue", Method: methodSelector, Location: locationBody, Keywords: []string{"div[data-v-app]"}},
+	}})
+
+	page := newTestResponse(200, nil, `<html><body><div id="app" data-v-app></div></body></html>`)
+	doc := extractPage(page)
+
+	// 响应体中没有该元素，只有使用传入的DOM时才会命中
+	resp := newTestResponse(200, nil, `<html><body></body></html>`)
+	var got []string
+	for _, tech := range s.identifyCMS(resp, doc) {
+		got = append(got, tech.Name)
+	}
+	if !reflect.DeepEqual(got, []string{"Vue"}) {
+		t.Errorf("identifyCMS() = %v, want [Vue]", got)
+	}
+	if got := identify(s, resp); got != nil {
+		t.Errorf("identify() without a document = %v, want none", got)
+	}
+}
+
+func TestKeywordRulesShareAutomaton(t *testing.T) {
+	s := newTestScanner(t, &model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		{CMS: "Seeyon", Method: methodKeyword, Location: locationBody, Keywords: []string{"/seeyon/", "A8"}},
+		{CMS: "SeeyonM1", Method: methodKeyword, Location: locationBody, Keywords: []string{"/seeyon/", "M1-Server"}},
+		{CMS: "Nginx", Method: methodKeyword, Location: locationHeader, Keywords: []string{"Server: nginx"}},
+	}})
+
+	tests := []struct {
+		name    string
+		headers map[string][]string
+		body    string
+		want    []string
+	}{
+		{"all keywords of one rule", nil, `<a href="/seeyon/">A8</a>`, []string{"Seeyon"}},
+		{"shared keyword only", nil, `<a href="/seeyon/">`, nil},
+		{"both rules", nil, `/seeyon/ A8 M1-Server`, []string{"Seeyon", "SeeyonM1"}},
+		{"keyword in another location", nil, `Server: nginx`, nil},
+		{"header location", map[string][]string{"server": {"nginx"}}, ``, []string{"Nginx"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := identify(s, newTestResponse(200, tt.headers, tt.body))
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("identify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileStatusOnlyRule(t *testing.T) {
+	tests := []struct {
+		name    string
+		fp      model.Fingerprint
+		wantErr bool
+	}{
+		{"method and location omitted", model.Fingerprint{StatusCode: 200}, false},
+		{"valid method and location", model.Fingerprint{Method: methodKeyword, Location: locationBody, StatusCode: 200}, false},
+		{"unknown method", model.Fingerprint{Method: "kyword", Location: locationBody, StatusCode: 200}, true},
+		{"unknown location", model.Fingerprint{Method: methodKeyword, Location: "bdy", StatusCode: 200}, true},
+		{"expr method needs an expression", model.Fingerprint{Method: methodExpr, StatusCode: 200}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fp.CMS = "Test"
+			r, err := compileRule(0, tt.fp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !r.statusOnly {
+				t.Error("compileRule() did not compile a status-only rule")
+			}
+		})
+	}
+}
+
+func TestApplyRelations(t *testing.T) {
+	rule := func(cms string, implies, excludes []string) model.Fingerprint {
+		return model.Fingerprint{CMS: cms, Method: methodKeyword, Location: locationBody, Keywords: []string{cms}, Implies: implies, Excludes: excludes}
+	}
+	engine := NewEngine(&model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		rule("WordPress", []string{"PHP"}, nil),
+		rule("Drupal", []string{"PHP"}, nil),
+		rule("PHP", []string{"Linux"}, nil),
+		rule("Hexo", []string{"Node.js"}, nil),
+		rule("Node.js", []string{"Hexo"}, nil),
+		rule("Tomcat", []string{"Java"}, []string{"IIS", "ASP.NET"}),
+		rule("Umbraco", []string{"ASP.NET"}, nil),
+		rule("IIS", []string{"Windows"}, nil),
+	}})
+	if len(engine.Errors) > 0 {
+		t.Fatalf("无效指纹: %v", engine.Errors)
+	}
+
+	// 被推断出的技术写作 名称<来源
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{"no relations", []string{"Nginx"}, []string{"Nginx"}},
+		{"implies chain", []string{"WordPress"}, []string{"WordPress", "PHP<WordPress", "Linux<PHP"}},
+		{"implied by several", []string{"WordPress", "Drupal"}, []string{"WordPress", "Drupal", "PHP<WordPress,Drupal", "Linux<PHP"}},
+		{"detected is not marked implied", []string{"WordPress", "PHP"}, []string{"WordPress", "PHP", "Linux<PHP"}},
+		{"cycle", []string{"Hexo"}, []string{"Hexo", "Node.js<Hexo"}},
+		{"cycle both detected", []string{"Node.js", "Hexo"}, []string{"Node.js", "Hexo"}},
+		{"excludes detected", []string{"IIS", "Tomcat"}, []string{"Tomcat", "Java<Tomcat"}},
+		{"excluded implies nothing", []string{"Tomcat", "IIS"}, []string{"Tomcat", "Java<Tomcat"}},
+		{"excludes implied", []string{"Tomcat", "Umbraco"}, []string{"Tomcat", "Umbraco", "Java<Tomcat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var techs []model.Technology
+			for _, name := range tt.input {
+				techs = append(techs, model.Technology{Name: name, Confidence: 80})
+			}
+			var got []string
+			for _, tech := range engine.applyRelations(techs) {
+				if !tech.Inferred {
+					got = append(got, tech.Name)
+					continue
+				}
+				if tech.Confidence != 80 {
+					t.Errorf("%s confidence = %d, want 80", tech.Name, tech.Confidence)
+				}
+				got = append(got, tech.Name+"<"+strings.Join(tech.ImpliedBy, ","))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyRelations() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"strconv"
+	"strings"
+)
+
+// snippetRadius 证据片段在命中位置前后保留的字节数
+const snippetRadius = 40
+
+// locationFavicon favicon证据的位置名称
+const locationFavicon = "favicon"
+
+// collectEvidence 收集已命中规则的证据
+func (s *Scanner) collectEvidence(r *rule, view *responseView) []model.Evidence {
+	var evidence []model.Evidence
+	addSnippet := func(location, pattern, snippet string) {
+		evidence = append(evidence, model.Evidence{
+			Rule:     r.id,
+			Path:     view.path,
+			Location: publicLocation(location),
+			Pattern:  pattern,
+			Snippet:  snippet,
+		})
+	}
+	add := func(location, pattern string, start, end int) {
+		content, _ := view.content(location)
+		addSnippet(location, pattern, utils.Snippet(content, start, end, snippetRadius))
+	}
+
+	if r.fp.StatusCode != 0 {
+		status := strconv.Itoa(r.fp.StatusCode)
+		add(locationStatus, status, 0, len(status))
+	}
+	if r.statusOnly {
+		return evidence
+	}
+
+	switch r.fp.Method {
+	case methodFaviconHash:
+		favicon, _ := matchedFavicon(view.resp, r.fp.HashAlgorithm, r.fp.Keywords[0])
+		addSnippet(locationFavicon, r.fp.Keywords[0], favicon.URL)
+	case methodKeyword:
+		content, _ := view.content(r.location)
+		for _, keyword := range r.fp.Keywords {
+			if isExactLocation(r.location) {
+				addSnippet(r.location, keyword, keyword)
+				continue
+			}
+			start := strings.Index(content, keyword)
+			add(r.location, keyword, start, start+len(keyword))
+		}
+	case methodRegular:
+		if isExactLocation(r.location) {
+			values := view.values(r.location)
+			for i, re := range r.patterns {
+				if j := matchedValue(values, re); j >= 0 {
+					addSnippet(r.location, r.fp.Keywords[i], values[j])
+				}
+			}
+			break
+		}
+		content, _ := view.content(r.location)
+		for i, re := range r.patterns {
+			if loc := re.FindStringIndex(content); loc != nil {
+				add(r.location, r.fp.Keywords[i], loc[0], loc[1])
+			}
+		}
+	case methodSelector:
+		doc := view.document()
+		for i, selector := range r.selectors {
+			element, _ := goquery.OuterHtml(doc.FindMatcher(selector).First())
+			addSnippet(locationBody, r.fp.Keywords[i], utils.Snippet(element, 0, 0, 2*snippetRadius))
+		}
+	case methodExpr:
+		for _, cond := range exprMatchedConds(r.expr, view) {
+			if isExactLocation(cond.location) {
+				addSnippet(cond.location, cond.value, view.values(cond.location)[cond.matchedValue(view)])
+				continue
+			}
+			content, _ := view.content(cond.location)
+			start, end := 0, len(content)
+			switch cond.op {
+			case "=":
+				start = strings.Index(content, cond.value)
+				end = start + len(cond.value)
+			case "~=":
+				loc := cond.re.FindStringIndex(content)
+				start, end = loc[0], loc[1]
+			}
+			add(cond.location, cond.value, start, end)
+		}
+	}
+	return evidence
+}
+
+// publicLocation 返回证据中展示的位置名称，旧版JSON头视图仍显示为header
+func publicLocation(location string) string {
+	if location == locationHeaderJSON {
+		return locationHeader
+	}
+	return location
+}
+
+// exprMatchedConds 收集表达式中使规则成立的肯定条件，取反的条件不作为证据
+func exprMatchedConds(node exprNode, view *responseView) []*exprCond {
+	switch n := node.(type) {
+	case *exprAnd:
+		return append(exprMatchedConds(n.left, view), exprMatchedConds(n.right, view)...)
+	case *exprOr:
+		var conds []*exprCond
+		if n.left.eval(view) {
+			conds = append(conds, exprMatchedConds(n.left, view)...)
+		}
+		if n.right.eval(view) {
+			conds = append(conds, exprMatchedConds(n.right, view)...)
+		}
+		return conds
+	case *exprCond:
+		if n.op != "!=" && n.eval(view) {
+			return []*exprCond{n}
+		}
+	}
+	return nil
+}
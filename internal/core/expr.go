@@ -0,0 +1,298 @@
+package core
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 表达式规则的语法：
+//
+//	expr := and ('||' and)*
+//	and  := unary ('&&' unary)*
+//	unary := '!' unary | '(' expr ')' | cond
+//	cond := location op "value"
+//
+// op为 = (包含)、== (完全相等)、!= (不包含)、~= (正则匹配)，
+// 例如 title="Jenkins" && !body="hudson-legacy"
+// status、body_hash、icon_hash 按取值逐个比较，= 与 == 相同，!= 表示不相等
+
+// exprNode 表达式语法树节点
+type exprNode interface {
+	eval(v *responseView) bool
+}
+
+// exprAnd 逻辑与
+type exprAnd struct {
+	left, right exprNode
+}
+
+func (n *exprAnd) eval(v *responseView) bool {
+	return n.left.eval(v) && n.right.eval(v)
+}
+
+// exprOr 逻辑或
+type exprOr struct {
+	left, right exprNode
+}
+
+func (n *exprOr) eval(v *responseView) bool {
+	return n.left.eval(v) || n.right.eval(v)
+}
+
+// exprNot 逻辑非
+type exprNot struct {
+	x exprNode
+}
+
+func (n *exprNot) eval(v *responseView) bool {
+	return !n.x.eval(v)
+}
+
+// exprCond 单个匹配条件
+type exprCond struct {
+	location string
+	op       string
+	value    string
+	re       *regexp.Regexp
+}
+
+func (n *exprCond) eval(v *responseView) bool {
+	if isExactLocation(n.location) {
+		return n.matchedValue(v) >= 0
+	}
+	content, ok := v.content(n.location)
+	if !ok {
+		// 不存在的响应头不包含任何值
+		return n.op == "!="
+	}
+	switch n.op {
+	case "=":
+		return strings.Contains(content, n.value)
+	case "==":
+		return content == n.value
+	case "!=":
+		return !strings.Contains(content, n.value)
+	case "~=":
+		return n.re.MatchString(content)
+	}
+	return false
+}
+
+// matchedValue 返回完全相等匹配位置上使条件成立的取值序号，不成立时返回-1
+// != 成立时没有对应的取值，返回0
+func (n *exprCond) matchedValue(v *responseView) int {
+	values := v.values(n.location)
+	switch n.op {
+	case "=", "==":
+		for i, value := range values {
+			if value == n.value {
+				return i
+			}
+		}
+	case "!=":
+		if !containsValue(values, n.value) {
+			return 0
+		}
+	case "~=":
+		return matchedValue(values, n.re)
+	}
+	return -1
+}
+
+// replaceExprLocation 将表达式中指定位置的条件改为读取另一位置
+func replaceExprLocation(node exprNode, from, to string) {
+	switch n := node.(type) {
+	case *exprAnd:
+		replaceExprLocation(n.left, from, to)
+		replaceExprLocation(n.right, from, to)
+	case *exprOr:
+		replaceExprLocation(n.left, from, to)
+		replaceExprLocation(n.right, from, to)
+	case *exprNot:
+		replaceExprLocation(n.x, from, to)
+	case *exprCond:
+		if n.location == from {
+			n.location = to
+		}
+	}
+}
+
+// exprUsesLocation 判断表达式中是否有读取指定位置的条件
+func exprUsesLocation(node exprNode, location string) bool {
+	switch n := node.(type) {
+	case *exprAnd:
+		return exprUsesLocation(n.left, location) || exprUsesLocation(n.right, location)
+	case *exprOr:
+		return exprUsesLocation(n.left, location) || exprUsesLocation(n.right, location)
+	case *exprNot:
+		return exprUsesLocation(n.x, location)
+	case *exprCond:
+		return n.location == location
+	}
+	return false
+}
+
+// exprParser 表达式解析器
+type exprParser struct {
+	src string
+	pos int
+}
+
+// parseExpr 解析并校验表达式
+func parseExpr(src string) (exprNode, error) {
+	p := &exprParser{src: src}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("多余的内容 %q", p.src[p.pos:])
+	}
+	return node, nil
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	switch {
+	case p.consume("!"):
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNot{x: x}, nil
+	case p.consume("("):
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(")") {
+			return nil, p.errorf("缺少 )")
+		}
+		return node, nil
+	}
+	return p.parseCond()
+}
+
+func (p *exprParser) parseCond() (exprNode, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.src) && isExprIdentChar(p.src[p.pos]) {
+		p.pos++
+	}
+	location := p.src[start:p.pos]
+	if location == "" {
+		return nil, p.errorf("缺少匹配位置")
+	}
+	if !isExprLocation(location) {
+		return nil, p.errorf("未知的匹配位置 %q", location)
+	}
+
+	var op string
+	for _, candidate := range []string{"==", "!=", "~=", "="} {
+		if p.consume(candidate) {
+			op = candidate
+			break
+		}
+	}
+	if op == "" {
+		return nil, p.errorf("缺少运算符")
+	}
+
+	value, err := p.parseString()
+	if err != nil {
+		return nil, err
+	}
+
+	cond := &exprCond{location: location, op: op, value: value}
+	if op == "~=" {
+		if cond.re, err = regexp.Compile(value); err != nil {
+			return nil, p.errorf("无效的正则 %q: %v", value, err)
+		}
+	}
+	return cond, nil
+}
+
+// parseString 解析双引号字符串，\" 和 \\ 为转义，其他反斜杠原样保留，如正则中的 \d
+func (p *exprParser) parseString() (string, error) {
+	if !p.consume(`"`) {
+		return "", p.errorf("缺少字符串")
+	}
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.src) && (p.src[p.pos+1] == '"' || p.src[p.pos+1] == '\\'):
+			sb.WriteByte(p.src[p.pos+1])
+			p.pos += 2
+		case c == '"':
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+	return "", p.errorf("字符串未闭合")
+}
+
+// consume 跳过空白后尝试匹配指定记号
+func (p *exprParser) consume(token string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.src[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *exprParser) errorf(format string, v ...interface{}) error {
+	return fmt.Errorf("表达式第%d个字符处: %s", p.pos+1, fmt.Sprintf(format, v...))
+}
+
+// isExprIdentChar 判断是否为位置名称字符
+func isExprIdentChar(c byte) bool {
+	return c == '_' || c == '-' || c == ':' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// isExprLocation 判断表达式中是否可以使用该位置
+func isExprLocation(location string) bool {
+	return location == locationIconHash || isMatchLocation(location)
+}
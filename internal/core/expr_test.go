@@ -0,0 +1,114 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"testing"
+)
+
+func TestParseExprString(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{`body="plain"`, `plain`},
+		{`body="say \"hi\""`, `say "hi"`},
+		{`body="C:\\"`, `C:\`},
+		{`body="a\\\"b"`, `a\"b`},
+		{`body~="\d+\.\d+"`, `\d+\.\d+`},
+	}
+	for _, tt := range tests {
+		node, err := parseExpr(tt.src)
+		if err != nil {
+			t.Errorf("parseExpr(%s) error: %v", tt.src, err)
+			continue
+		}
+		if got := node.(*exprCond).value; got != tt.want {
+			t.Errorf("parseExpr(%s) value = %q, want %q", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestExprConditionRoundTrip(t *testing.T) {
+	for _, value := range []string{`plain`, `quote"inside`, `ends with\`, `\\double`, `(?i)path\\to\"x`, `\d+$`} {
+		src := exprCondition(locationBody, "=", value)
+		node, err := parseExpr(src)
+		if err != nil {
+			t.Errorf("parseExpr(%s) error: %v", src, err)
+			continue
+		}
+		if got := node.(*exprCond).value; got != value {
+			t.Errorf("round trip of %q = %q via %s", value, got, src)
+		}
+	}
+}
+
+func TestEvalExpr(t *testing.T) {
+	resp := newTestResponse(200, map[string][]string{"Server": {"nginx/1.24"}},
+		`<html><head><title>Login</title></head><body>version 1.2 say "hi" C:\path</body></html>`)
+	view := newResponseView(NewEngine(&model.FingerprintDB{}), resp)
+
+	tests := []struct {
+		src  string
+		want bool
+	}{
+		// 运算符
+		{`title="Log"`, true},
+		{`title=="Log"`, false},
+		{`title=="Login"`, true},
+		{`body!="nginx"`, true},
+		{`body!="Login"`, false},
+		{`body~="version \d+\.\d+"`, true},
+		{`header~="(?m)^Server: nginx/1\.\d+"`, true},
+		{`status=="200"`, true},
+		{`status="20"`, false},
+		{`header:X-Missing!="a"`, true},
+		{`header:X-Missing=""`, false},
+
+		// && 优先于 ||
+		{`body="Login" || body="absent1" && body="absent2"`, true},
+		{`(body="Login" || body="absent1") && body="absent2"`, false},
+		{`body="absent1" && body="absent2" || title=="Login"`, true},
+		{`body="absent1" && (body="absent2" || title=="Login")`, false},
+
+		// ! 只作用于紧随其后的条件或括号
+		{`!body="absent1"`, true},
+		{`!body="Login" || title=="Login"`, true},
+		{`!(body="Login" || title=="Login")`, false},
+		{`!!body="Login"`, true},
+		{`!body="absent1" && !header:X-Missing="a"`, true},
+
+		// 转义
+		{`body="say \"hi\""`, true},
+		{`body="C:\\path"`, true},
+		{`body="C:\path"`, true},
+	}
+	for _, tt := range tests {
+		node, err := parseExpr(tt.src)
+		if err != nil {
+			t.Errorf("parseExpr(%s) error: %v", tt.src, err)
+			continue
+		}
+		if got := node.eval(view); got != tt.want {
+			t.Errorf("eval(%s) = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseExprErrors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`body="a" &&`,
+		`(body="a"`,
+		`body="a")`,
+		`unknown="a"`,
+		`body "a"`,
+		`body=a`,
+		`body="unterminated`,
+		`body~="("`,
+		`!`,
+	} {
+		if _, err := parseExpr(src); err == nil {
+			t.Errorf("parseExpr(%s) succeeded, want error", src)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package core
+
+import (
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"regexp"
+	"strconv"
+)
+
+// hexHashRe 小写十六进制的md5和sha256
+var hexHashRe = regexp.MustCompile(`^[0-9a-f]+$`)
+
+// faviconhash规则支持的哈希算法
+const (
+	hashMMH3   = "mmh3"   // FOFA、Shodan使用，默认算法
+	hashMD5    = "md5"    // Hunter、Quake使用
+	hashSHA256 = "sha256" // sha256
+)
+
+// validateHashAlgorithm 校验faviconhash规则的哈希算法
+func validateHashAlgorithm(fp model.Fingerprint) error {
+	if fp.HashAlgorithm == "" {
+		return nil
+	}
+	if fp.Method != methodFaviconHash {
+		return fmt.Errorf("hash_algorithm 只能用于 faviconhash 规则")
+	}
+	switch fp.HashAlgorithm {
+	case hashMMH3, hashMD5, hashSHA256:
+		return nil
+	}
+	return fmt.Errorf("未知的hash_algorithm %q", fp.HashAlgorithm)
+}
+
+// isFaviconHashFormat 判断哈希值是否符合算法的格式
+func isFaviconHashFormat(hash, algorithm string) bool {
+	switch algorithm {
+	case hashMD5:
+		return len(hash) == 32 && hexHashRe.MatchString(hash)
+	case hashSHA256:
+		return len(hash) == 64 && hexHashRe.MatchString(hash)
+	}
+	_, err := strconv.ParseInt(hash, 10, 32)
+	return err == nil
+}
+
+// faviconHash 返回图标指定算法的哈希值，算法为空时使用mmh3
+func faviconHash(favicon model.Favicon, algorithm string) string {
+	switch algorithm {
+	case hashMD5:
+		return favicon.MD5
+	case hashSHA256:
+		return favicon.SHA256
+	}
+	return favicon.MMH3
+}
+
+// matchedFavicon 返回指定算法的哈希值与hash相等的第一个图标
+func matchedFavicon(resp *model.HTTPResponse, algorithm, hash string) (model.Favicon, bool) {
+	for _, favicon := range resp.Favicons {
+		if faviconHash(favicon, algorithm) == hash {
+			return favicon, true
+		}
+	}
+	return model.Favicon{}, false
+}
+
+// faviconValues 返回全部图标各算法的哈希值，供表达式中的icon_hash使用
+func faviconValues(resp *model.HTTPResponse) []string {
+	var values []string
+	for _, favicon := range resp.Favicons {
+		values = append(values, favicon.MMH3, favicon.MD5, favicon.SHA256)
+	}
+	return values
+}
@@ -3,8 +3,14 @@ package core
 import (
 	_ "embed"
 	"encoding/json"
+	"fmt"
 	"github.com/kN6jq/fingerScan/internal/model"
-	"regexp"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"github.com/kN6jq/fingerScan/pkg/logger"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -20,15 +26,217 @@ func LoadFingerprints() (*model.FingerprintDB, error) {
 	return &db, nil
 }
 
+// LoadFingerprintFiles 从文件或目录加载外部指纹库
+func LoadFingerprintFiles(paths []string) (*model.FingerprintDB, error) {
+	files, err := ConvertFingerprintPaths(paths, "")
+	if err != nil {
+		return nil, err
+	}
+	db := &model.FingerprintDB{}
+	for _, file := range files {
+		logConvertNotes(file.Name, file.Notes)
+		db.Fingerprints = append(db.Fingerprints, file.DB.Fingerprints...)
+	}
+	return db, nil
+}
+
+// LoadFingerprintFile 加载单个指纹文件，自动识别文件格式
+func LoadFingerprintFile(filename string) (*model.FingerprintDB, error) {
+	db, notes, err := ConvertFingerprintFile(filename, "")
+	if err != nil {
+		return nil, err
+	}
+	logConvertNotes(filename, notes)
+	return db, nil
+}
+
+// logConvertNotes 以调试级别输出转换说明
+func logConvertNotes(filename string, notes []ConvertNote) {
+	for _, note := range notes {
+		if note.Partial {
+			logger.Debug("%s 中的规则未提取版本: %s", filename, note.Message)
+		} else {
+			logger.Debug("%s 中的规则无法转换: %s", filename, note.Message)
+		}
+	}
+}
+
+// ConvertedFile 转换后的单个指纹文件
+type ConvertedFile struct {
+	Name  string               // 文件路径
+	DB    *model.FingerprintDB // 转换出的指纹
+	Notes []ConvertNote        // 转换说明
+}
+
+// ConvertFingerprintPaths 转换文件或目录中的全部指纹文件，format为空时自动识别
+// 被规则引用为测试样本的文件不视为指纹文件，样本可以和规则放在同一目录中
+func ConvertFingerprintPaths(paths []string, format string) ([]ConvertedFile, error) {
+	var converted []ConvertedFile
+	var failed []string
+	errs := make(map[string]error)
+	for _, path := range paths {
+		files, err := CollectFingerprintFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			db, notes, err := ConvertFingerprintFile(file, format)
+			if err != nil {
+				// 可能是后面的规则引用的样本，全部转换后再判断
+				failed = append(failed, file)
+				errs[file] = err
+				continue
+			}
+			converted = append(converted, ConvertedFile{Name: file, DB: db, Notes: notes})
+		}
+	}
+
+	fixtures := fixtureFiles(converted)
+	for _, file := range failed {
+		if !fixtures[filepath.Clean(file)] {
+			return nil, errs[file]
+		}
+	}
+	files := converted[:0]
+	for _, file := range converted {
+		if !fixtures[filepath.Clean(file.Name)] {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// fixtureFiles 收集规则引用的全部样本文件路径
+func fixtureFiles(files []ConvertedFile) map[string]bool {
+	fixtures := make(map[string]bool)
+	for _, file := range files {
+		for _, fp := range file.DB.Fingerprints {
+			if fp.Fixtures == nil {
+				continue
+			}
+			for _, path := range append(append([]string(nil), fp.Fixtures.Positive...), fp.Fixtures.Negative...) {
+				fixtures[filepath.Clean(path)] = true
+			}
+		}
+	}
+	return fixtures
+}
+
+// ConvertFingerprintFile 按指定格式转换指纹文件，format为空时自动识别
+func ConvertFingerprintFile(filename, format string) (*model.FingerprintDB, []ConvertNote, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if format == "" {
+		if format = DetectFormat(filename, data); format == "" {
+			return nil, nil, fmt.Errorf("无法识别指纹文件 %s 的格式", filename)
+		}
+	}
+
+	db, notes, err := ConvertFingerprints(format, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析指纹文件 %s 失败: %v", filename, err)
+	}
+	resolveFixtures(db, filepath.Dir(filename))
+	return db, notes, nil
+}
+
+// CollectFingerprintFiles 展开路径，目录中的json和yaml文件均视为指纹文件
+func CollectFingerprintFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".json", ".yaml", ".yml":
+			files = append(files, p)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// LoadFingerprintsWithFiles 加载内置指纹库并合并外部指纹文件
+// replace为true时外部指纹替换内置指纹，否则追加到内置指纹之后
+func LoadFingerprintsWithFiles(paths []string, replace bool) (*model.FingerprintDB, error) {
+	if len(paths) == 0 {
+		return LoadFingerprints()
+	}
+
+	external, err := LoadFingerprintFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	if replace {
+		return MergeFingerprints(external), nil
+	}
+
+	embedded, err := LoadFingerprints()
+	if err != nil {
+		return nil, err
+	}
+	return MergeFingerprints(embedded, external), nil
+}
+
+// MergeFingerprints 合并多个指纹库并按CMS、方法、位置和关键字去重
+func MergeFingerprints(dbs ...*model.FingerprintDB) *model.FingerprintDB {
+	merged := &model.FingerprintDB{}
+	seen := make(map[string]bool)
+	for _, db := range dbs {
+		if db == nil {
+			continue
+		}
+		for _, fp := range db.Fingerprints {
+			key := fingerprintKey(fp)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged.Fingerprints = append(merged.Fingerprints, fp)
+		}
+	}
+	return merged
+}
+
+// fingerprintKey 生成指纹去重键，探测请求或期望状态码不同的规则不视为重复
+func fingerprintKey(fp model.Fingerprint) string {
+	return strings.Join([]string{fp.CMS, fp.Method, fp.Location, strings.Join(fp.Keywords, "\x00"), fp.Expr, fp.HeaderFormat, fp.HashAlgorithm,
+		probeKey(fp), strconv.Itoa(fp.StatusCode)}, "\x01")
+}
+
 // GetFingerprint 获取指定CMS的指纹
 func GetFingerprint(db *model.FingerprintDB, cms string) []model.Fingerprint {
 	var fingerprints []model.Fingerprint
-	for _, fp := range db.Fingerprints {
+	for _, i := range fingerprintIndexes(db, cms) {
+		fingerprints = append(fingerprints, db.Fingerprints[i])
+	}
+	return fingerprints
+}
+
+// fingerprintIndexes 获取指定CMS的指纹在指纹库中的位置
+func fingerprintIndexes(db *model.FingerprintDB, cms string) []int {
+	var indexes []int
+	for i, fp := range db.Fingerprints {
 		if fp.CMS == cms {
-			fingerprints = append(fingerprints, fp)
+			indexes = append(indexes, i)
 		}
 	}
-	return fingerprints
+	return indexes
 }
 
 // MatchFingerprint 匹配指纹是否符合目标
@@ -40,20 +248,9 @@ func MatchFingerprint(fp model.Fingerprint, target map[string]string) bool {
 
 	switch fp.Method {
 	case "keyword":
-		for _, keyword := range fp.Keywords {
-			if !strings.Contains(content, keyword) {
-				return false
-			}
-		}
-		return true
+		return utils.ContainsAllKeywords(content, fp.Keywords)
 	case "regular":
-		for _, pattern := range fp.Keywords {
-			matched, err := regexp.MatchString(pattern, content)
-			if err != nil || !matched {
-				return false
-			}
-		}
-		return true
+		return utils.MatchesAllPatterns(content, fp.Keywords)
 	}
 	return false
 }
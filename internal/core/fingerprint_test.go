@@ -0,0 +1,32 @@
+package core
+
+import "testing"
+
+func TestLoadFingerprintDirectoryWithFixtures(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"jenkins.json": `{"fingerprint": [{"cms": "Jenkins", "method": "keyword", "location": "header", "keyword": ["X-Jenkins"],
+			"fixtures": {"positive": ["fixtures/jenkins.json"], "negative": ["fixtures/nginx.json"]}}]}`,
+		"fixtures/jenkins.json": `{"headers": {"X-Jenkins": ["2.426"]}, "body": "<title>Dashboard [Jenkins]</title>"}`,
+		"fixtures/nginx.json":   `{"headers": {"Server": ["nginx"]}, "body": "<title>Welcome to nginx!</title>"}`,
+	})
+
+	db, err := LoadFingerprintFiles([]string{dir})
+	if err != nil {
+		t.Fatalf("LoadFingerprintFiles() error: %v", err)
+	}
+	if len(db.Fingerprints) != 1 {
+		t.Fatalf("LoadFingerprintFiles() loaded %d rules, want 1", len(db.Fingerprints))
+	}
+	for _, result := range TestFingerprints(db) {
+		if len(result.Failures) > 0 || result.Passed != 2 {
+			t.Errorf("TestFingerprints() = %+v, want 2 passed samples", result)
+		}
+	}
+
+	// 没有被规则引用的文件仍然必须是指纹文件
+	writeFiles(t, dir, map[string]string{"fixtures/stray.json": `{"headers": {}}`})
+	if _, err := LoadFingerprintFiles([]string{dir}); err == nil {
+		t.Error("LoadFingerprintFiles() accepted an unreferenced non-fingerprint file")
+	}
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"net/textproto"
+	"os"
+	"path/filepath"
+)
+
+// FixtureResult 单条指纹的样本测试结果
+type FixtureResult struct {
+	Index      int      `json:"index"`              // 指纹序号
+	Rule       string   `json:"rule"`               // 规则标识
+	CMS        string   `json:"cms"`                // CMS名称
+	NoFixtures bool     `json:"no_fixtures"`        // 没有配置测试样本
+	Failures   []string `json:"failures,omitempty"` // 失败说明
+	Passed     int      `json:"passed"`             // 通过的样本数量
+}
+
+// LoadSample 读取保存的响应样本
+func LoadSample(filename string) (*model.HTTPResponse, error) {
+	resp, _, err := loadSample(filename)
+	return resp, err
+}
+
+// loadSample 读取保存的响应样本，同时返回响应体解析出的DOM
+func loadSample(filename string) (*model.HTTPResponse, *goquery.Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var sample model.Sample
+	if err := json.Unmarshal(data, &sample); err != nil {
+		return nil, nil, fmt.Errorf("解析样本 %s 失败: %v", filename, err)
+	}
+
+	dir := filepath.Dir(filename)
+	if sample.BodyFile != "" {
+		body, err := os.ReadFile(resolvePath(dir, sample.BodyFile))
+		if err != nil {
+			return nil, nil, err
+		}
+		sample.Body = string(body)
+	}
+
+	headers := make(map[string][]string, len(sample.Headers))
+	for name, values := range sample.Headers {
+		key := textproto.CanonicalMIMEHeaderKey(name)
+		headers[key] = append(headers[key], values...)
+	}
+
+	resp := &model.HTTPResponse{
+		URL:         sample.URL,
+		Body:        sample.Body,
+		Headers:     headers,
+		Server:      extractServer(headers),
+		StatusCode:  sample.StatusCode,
+		Length:      len(sample.Body),
+		FaviconHash: "0",
+	}
+	doc := extractPage(resp)
+	if resp.StatusCode == 0 {
+		resp.StatusCode = 200
+	}
+	if sample.Favicon != "" {
+		favicon, err := os.ReadFile(resolvePath(dir, sample.Favicon))
+		if err != nil {
+			return nil, nil, err
+		}
+		resp.Favicons = []model.Favicon{utils.FaviconHashes(sample.Favicon, favicon)}
+		resp.FaviconHash = resp.Favicons[0].MMH3
+	}
+	return resp, doc, nil
+}
+
+// resolvePath 将相对路径解析为相对于dir的路径
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// resolveFixtures 将指纹中样本的相对路径解析为相对于指纹文件目录的路径
+func resolveFixtures(db *model.FingerprintDB, dir string) {
+	for i := range db.Fingerprints {
+		fixtures := db.Fingerprints[i].Fixtures
+		if fixtures == nil {
+			continue
+		}
+		for j, path := range fixtures.Positive {
+			fixtures.Positive[j] = resolvePath(dir, path)
+		}
+		for j, path := range fixtures.Negative {
+			fixtures.Negative[j] = resolvePath(dir, path)
+		}
+	}
+}
+
+// TestFingerprints 使用样本逐条测试指纹，规则与扫描时一样经由matchFingerprint匹配
+func TestFingerprints(db *model.FingerprintDB) []FixtureResult {
+	engine := NewEngine(db)
+	s := &Scanner{}
+	s.engine.Store(engine)
+	compiled := make(map[int]*rule, len(engine.rules))
+	for _, r := range engine.rules {
+		compiled[r.index] = r
+	}
+
+	// 样本及其DOM在各规则间共用
+	type loadedSample struct {
+		resp *model.HTTPResponse
+		doc  *goquery.Document
+	}
+	samples := make(map[string]loadedSample)
+	load := func(path string) (loadedSample, error) {
+		if sample, ok := samples[path]; ok {
+			return sample, nil
+		}
+		resp, doc, err := loadSample(path)
+		if err != nil {
+			return loadedSample{}, err
+		}
+		samples[path] = loadedSample{resp: resp, doc: doc}
+		return samples[path], nil
+	}
+
+	var results []FixtureResult
+	for i, fp := range db.Fingerprints {
+		result := FixtureResult{Index: i, Rule: ruleID(fp, i), CMS: fp.CMS}
+		r, ok := compiled[i]
+		if fp.Fixtures == nil || len(fp.Fixtures.Positive)+len(fp.Fixtures.Negative) == 0 {
+			result.NoFixtures = true
+			results = append(results, result)
+			continue
+		}
+		if !ok {
+			_, err := compileRule(i, fp)
+			result.Failures = append(result.Failures, fmt.Sprintf("规则无效: %v", err))
+			results = append(results, result)
+			continue
+		}
+
+		check := func(path string, want bool) {
+			sample, err := load(path)
+			if err != nil {
+				result.Failures = append(result.Failures, fmt.Sprintf("读取样本失败: %v", err))
+				return
+			}
+			view := newResponseView(engine, sample.resp)
+			view.path = fp.Path
+			view.doc = sample.doc
+			switch got := s.matchFingerprint(r, view); {
+			case got == want:
+				result.Passed++
+			case want:
+				result.Failures = append(result.Failures, "正样本未命中: "+path)
+			default:
+				result.Failures = append(result.Failures, "负样本被误命中: "+path)
+			}
+		}
+		for _, path := range fp.Fixtures.Positive {
+			check(path, true)
+		}
+		for _, path := range fp.Fixtures.Negative {
+			check(path, false)
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestScanner 使用给定指纹创建不发起请求的扫描器，指纹存在无效规则时测试失败
+func newTestScanner(t *testing.T, db *model.FingerprintDB) *Scanner {
+	t.Helper()
+	engine := NewEngine(db)
+	if len(engine.Errors) > 0 {
+		t.Fatalf("无效指纹: %v", engine.Errors)
+	}
+	s := &Scanner{}
+	s.engine.Store(engine)
+	return s
+}
+
+// identify 返回响应命中的技术名称
+func identify(s *Scanner, resp *model.HTTPResponse) []string {
+	var names []string
+	for _, tech := range s.identifyCMS(resp, nil) {
+		names = append(names, tech.Name)
+	}
+	return names
+}
+
+// newTestResponse 创建测试用的响应，并像请求时一样解析页面
+func newTestResponse(status int, headers map[string][]string, body string) *model.HTTPResponse {
+	resp := &model.HTTPResponse{
+		URL:        "http://example.com/",
+		Body:       body,
+		Headers:    headers,
+		StatusCode: status,
+		Length:     len(body),
+	}
+	extractPage(resp)
+	return resp
+}
+
+// writeFiles 在dir下写入测试文件，路径中的目录自动创建
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
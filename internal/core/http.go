@@ -2,10 +2,14 @@
 package core
 
 import (
+	"encoding/json"
+	"fmt"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/imroc/req/v3"
 	"github.com/kN6jq/fingerScan/internal/model"
 	"github.com/kN6jq/fingerScan/internal/utils"
+	"io"
+	"net/http"
 	"net/url"
 	"strings"
 	"time"
@@ -13,6 +17,10 @@ import (
 
 const (
 	defaultTimeout = 5 * time.Second
+	maxFavicons    = 8 // 每个页面最多请求的图标数量
+
+	maxFaviconSize  = 1 << 20   // 单个图标的最大读取字节数
+	maxManifestSize = 256 << 10 // manifest的最大读取字节数
 )
 
 // HTTPClient 封装HTTP客户端功能
@@ -41,84 +49,219 @@ func NewHTTPClient(proxy string) *HTTPClient {
 
 // DoRequest 执行HTTP请求
 func (c *HTTPClient) DoRequest(urlStr string) (*model.HTTPResponse, error) {
+	result, _, err := c.doRequest(urlStr)
+	return result, err
+}
+
+// doRequest 执行HTTP请求，同时返回响应体解析出的DOM
+func (c *HTTPClient) doRequest(urlStr string) (*model.HTTPResponse, *goquery.Document, error) {
 	resp, err := c.client.R().Get(urlStr)
 	if err != nil {
 		// 尝试HTTP协议
 		urlStr = strings.ReplaceAll(urlStr, "https://", "http://")
 		resp, err = c.client.R().Get(urlStr)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	body, err := resp.ToString()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	server := extractServer(resp.Header)
+	finalURL, redirects := redirectChain(resp.Response)
+
+	result := &model.HTTPResponse{
+		URL:        urlStr,
+		FinalURL:   finalURL,
+		Redirects:  redirects,
+		Body:       body,
+		Headers:    resp.Header,
+		Server:     server,
+		StatusCode: resp.StatusCode,
+		Length:     len(body),
+		JSURLs:     utils.ExtractJSURLs(body, urlStr),
 	}
+	doc := extractPage(result)
+	result.Favicons = c.getFavicons(result)
+	result.FaviconHash = firstFaviconHash(result.Favicons)
+	return result, doc, nil
+}
 
-	title := c.extractTitle(body)
-	server := c.extractServer(resp.Header)
-	faviconHash := c.getFaviconHash(body, urlStr)
-
-	return &model.HTTPResponse{
-		URL:         urlStr,
-		Body:        body,
-		Headers:     resp.Header,
-		Server:      server,
-		StatusCode:  resp.StatusCode,
-		Length:      len(body),
-		Title:       title,
-		JSURLs:      utils.ExtractJSURLs(body, urlStr),
-		FaviconHash: faviconHash,
-	}, nil
+// DoProbe 执行主动探测请求，不处理JS跳转和favicon，需要时由调用方计算图标哈希
+func (c *HTTPClient) DoProbe(urlStr, method string, headers map[string]string, body string) (*model.HTTPResponse, error) {
+	result, _, err := c.doProbe(urlStr, method, headers, body)
+	return result, err
 }
 
-// extractTitle 提取网页标题
-func (c *HTTPClient) extractTitle(body string) string {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+// doProbe 执行主动探测请求，同时返回响应体解析出的DOM
+func (c *HTTPClient) doProbe(urlStr, method string, headers map[string]string, body string) (*model.HTTPResponse, *goquery.Document, error) {
+	r := c.client.R().SetHeaders(headers)
+	if body != "" {
+		r.SetBodyString(body)
+	}
+	resp, err := r.Send(method, urlStr)
 	if err != nil {
-		return "Not found"
+		return nil, nil, err
 	}
-	title := doc.Find("title").Text()
-	return strings.TrimSpace(strings.ReplaceAll(title, "\n", ""))
+
+	respBody, err := resp.ToString()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	finalURL, redirects := redirectChain(resp.Response)
+
+	result := &model.HTTPResponse{
+		URL:        urlStr,
+		FinalURL:   finalURL,
+		Redirects:  redirects,
+		Body:       respBody,
+		Headers:    resp.Header,
+		Server:     extractServer(resp.Header),
+		StatusCode: resp.StatusCode,
+		Length:     len(respBody),
+	}
+	doc := extractPage(result)
+	return result, doc, nil
+}
+
+// FetchScript 请求脚本内容，最多读取maxSize字节
+func (c *HTTPClient) FetchScript(urlStr string, maxSize int64) (string, error) {
+	data, err := c.fetchLimited(urlStr, maxSize)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// fetchLimited 请求静态资源，状态码不为200时返回错误，最多读取maxSize字节
+func (c *HTTPClient) fetchLimited(urlStr string, maxSize int64) ([]byte, error) {
+	resp, err := c.client.R().DisableAutoReadResponse().Get(urlStr)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s 返回状态码 %d", urlStr, resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSize))
+}
+
+// redirectChain 获取跟随跳转后的最终URL，以及跳转过程中依次返回的Location
+func redirectChain(resp *http.Response) (string, []string) {
+	if resp == nil || resp.Request == nil {
+		return "", nil
+	}
+	var redirects []string
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		redirects = append([]string{req.Response.Header.Get("Location")}, redirects...)
+	}
+	return resp.Request.URL.String(), redirects
 }
 
 // extractServer 提取服务器信息
-func (c *HTTPClient) extractServer(headers map[string][]string) string {
-	if server := headers["Server"]; len(server) > 0 {
+func extractServer(headers map[string][]string) string {
+	if server := utils.HeaderValues(headers, "Server"); len(server) > 0 {
 		return server[0]
 	}
-	if powered := headers["X-Powered-By"]; len(powered) > 0 {
+	if powered := utils.HeaderValues(headers, "X-Powered-By"); len(powered) > 0 {
 		return powered[0]
 	}
 	return "None"
 }
 
-// getFaviconHash 获取favicon哈希值
-func (c *HTTPClient) getFaviconHash(body, urlStr string) string {
-	faviconURL := c.getFaviconURL(body, urlStr)
-	return utils.CalculateFaviconHash(faviconURL)
+// getFavicons 计算页面声明的图标和manifest中的图标的哈希值，都没有声明时使用 /favicon.ico
+func (c *HTTPClient) getFavicons(resp *model.HTTPResponse) []model.Favicon {
+	pageURL := resp.FinalURL
+	if pageURL == "" {
+		pageURL = resp.URL
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	candidates := append(append([]string(nil), resp.Icons...), c.manifestIcons(base, resp.Manifest)...)
+	if len(candidates) == 0 {
+		candidates = []string{"/favicon.ico"}
+	}
+	if len(candidates) > maxFavicons {
+		candidates = candidates[:maxFavicons]
+	}
+
+	var favicons []model.Favicon
+	seen := make(map[string]bool)
+	for _, href := range candidates {
+		if strings.HasPrefix(href, "data:") {
+			// data URI直接解码，结果中只记录媒体类型部分
+			name := href
+			if i := strings.IndexByte(href, ','); i >= 0 {
+				name = href[:i]
+			}
+			if data := decodeDataURI(href); data != "" && !seen[href] {
+				seen[href] = true
+				favicons = append(favicons, utils.FaviconHashes(name, []byte(data)))
+			}
+			continue
+		}
+
+		u, err := base.Parse(href)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		favicon, err := c.fetchLimited(u.String(), maxFaviconSize)
+		if err != nil || len(favicon) == 0 {
+			continue
+		}
+		favicons = append(favicons, utils.FaviconHashes(u.String(), favicon))
+	}
+	return favicons
 }
 
-// getFaviconURL 获取favicon URL
-func (c *HTTPClient) getFaviconURL(body, urlStr string) string {
-	u, err := url.Parse(urlStr)
+// manifestIcons 获取manifest中声明的图标地址，相对地址按manifest地址解析
+func (c *HTTPClient) manifestIcons(base *url.URL, manifest string) []string {
+	if manifest == "" {
+		return nil
+	}
+	manifestURL, err := base.Parse(manifest)
 	if err != nil {
-		return ""
-	}
-	baseURL := u.Scheme + "://" + u.Host
-
-	faviconPaths := utils.ExtractFaviconPaths(body)
-	if len(faviconPaths) > 0 {
-		fav := faviconPaths[0]
-		switch {
-		case strings.HasPrefix(fav, "//"):
-			return "http:" + fav
-		case strings.HasPrefix(fav, "http"):
-			return fav
-		default:
-			return baseURL + "/" + strings.TrimPrefix(fav, "/")
+		return nil
+	}
+	data, err := c.fetchLimited(manifestURL.String(), maxManifestSize)
+	if err != nil {
+		return nil
+	}
+
+	var m struct {
+		Icons []struct {
+			Src string `json:"src"`
+		} `json:"icons"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	var icons []string
+	for _, icon := range m.Icons {
+		if strings.HasPrefix(icon.Src, "data:") {
+			icons = append(icons, icon.Src)
+			continue
+		}
+		if u, err := manifestURL.Parse(icon.Src); err == nil {
+			icons = append(icons, u.String())
 		}
 	}
-	return baseURL + "/favicon.ico"
+	return icons
+}
+
+// firstFaviconHash 返回第一个图标的mmh3，没有图标时返回0
+func firstFaviconHash(favicons []model.Favicon) string {
+	if len(favicons) == 0 {
+		return "0"
+	}
+	return favicons[0].MMH3
 }
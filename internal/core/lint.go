@@ -0,0 +1,263 @@
+package core
+
+import (
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 检查问题的级别
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintIssue 指纹库检查发现的问题
+type LintIssue struct {
+	Index   int    `json:"index"`   // 指纹序号
+	CMS     string `json:"cms"`     // CMS名称
+	Level   string `json:"level"`   // 问题级别
+	Message string `json:"message"` // 问题说明
+}
+
+// LintFingerprints 检查指纹库中的无效规则、重复规则和容易回溯爆炸的正则
+func LintFingerprints(db *model.FingerprintDB) []LintIssue {
+	l := &linter{}
+	exact := make(map[string]int)
+	similar := make(map[string][]int)
+
+	for i, fp := range db.Fingerprints {
+		if strings.TrimSpace(fp.CMS) == "" {
+			l.add(i, fp, LintError, "CMS名称为空")
+		}
+
+		r, err := compileRule(i, fp)
+		if err != nil {
+			l.add(i, fp, LintError, err.Error())
+			continue
+		}
+		l.lintRule(r)
+
+		key := fingerprintKey(fp)
+		if first, ok := exact[key]; ok {
+			l.add(i, fp, LintError, fmt.Sprintf("与指纹 #%d 完全重复", first))
+			continue
+		}
+		exact[key] = i
+
+		if fp.Method == methodKeyword || fp.Method == methodRegular {
+			// 探测不同页面的规则各自独立，不参与近似比较
+			group := strings.Join([]string{strings.ToLower(fp.CMS), fp.Method, r.location, probeKey(fp), strconv.Itoa(fp.StatusCode)}, "\x00")
+			similar[group] = append(similar[group], i)
+		}
+	}
+
+	l.lintSimilar(db, similar)
+	sort.Slice(l.issues, func(i, j int) bool {
+		if l.issues[i].Index != l.issues[j].Index {
+			return l.issues[i].Index < l.issues[j].Index
+		}
+		return l.issues[i].Message < l.issues[j].Message
+	})
+	return l.issues
+}
+
+// linter 检查状态
+type linter struct {
+	issues []LintIssue
+}
+
+func (l *linter) add(index int, fp model.Fingerprint, level, message string) {
+	l.issues = append(l.issues, LintIssue{Index: index, CMS: fp.CMS, Level: level, Message: message})
+}
+
+// lintRule 检查已能编译的规则
+func (l *linter) lintRule(r *rule) {
+	fp := r.fp
+	for _, keyword := range fp.Keywords {
+		if keyword == "" {
+			l.add(r.index, fp, LintWarning, "包含空关键字，该关键字总是命中")
+		}
+	}
+	if fp.Method == methodFaviconHash {
+		if len(fp.Keywords) > 1 {
+			l.add(r.index, fp, LintWarning, "faviconhash 只使用第一个关键字")
+		}
+		if !isFaviconHashFormat(fp.Keywords[0], fp.HashAlgorithm) {
+			algorithm := fp.HashAlgorithm
+			if algorithm == "" {
+				algorithm = hashMMH3
+			}
+			l.add(r.index, fp, LintWarning, fmt.Sprintf("faviconhash %q 不是%s格式", fp.Keywords[0], algorithm))
+		}
+	}
+
+	// header位置默认使用原始格式，按旧版JSON视图编写的规则不再命中
+	if r.location == locationHeader {
+		for _, keyword := range fp.Keywords {
+			if hasJSONHeaderArtifact(keyword, fp.Method == methodRegular) {
+				l.add(r.index, fp, LintWarning, fmt.Sprintf("关键字 %q 疑似旧版JSON响应头格式，需设置 header_format: json", keyword))
+			}
+		}
+	}
+	if r.expr != nil {
+		for _, cond := range exprConditions(r.expr, locationHeader) {
+			if hasJSONHeaderArtifact(cond.value, cond.re != nil) {
+				l.add(r.index, fp, LintWarning, fmt.Sprintf("表达式取值 %q 疑似旧版JSON响应头格式，需设置 header_format: json", cond.value))
+			}
+		}
+	}
+
+	if fp.CPE != "" && !strings.HasPrefix(fp.CPE, "cpe:2.3:") {
+		l.add(r.index, fp, LintWarning, fmt.Sprintf("CPE %q 不是2.3格式", fp.CPE))
+	}
+
+	var patterns []string
+	switch fp.Method {
+	case methodRegular:
+		patterns = fp.Keywords
+	case methodExpr:
+		patterns = exprPatterns(r.expr)
+	}
+	for _, pattern := range patterns {
+		if reason := backtrackRisk(pattern); reason != "" {
+			l.add(r.index, fp, LintWarning, fmt.Sprintf("正则 %q %s，在回溯型正则引擎中可能导致灾难性回溯", pattern, reason))
+		}
+	}
+}
+
+// lintSimilar 检查同一CMS、方法、位置和探测请求下关键字相同或互为子集的规则
+func (l *linter) lintSimilar(db *model.FingerprintDB, groups map[string][]int) {
+	for _, indexes := range groups {
+		for a := 0; a < len(indexes); a++ {
+			for b := a + 1; b < len(indexes); b++ {
+				i, j := indexes[a], indexes[b]
+				ki := normalizedKeywords(db.Fingerprints[i].Keywords)
+				kj := normalizedKeywords(db.Fingerprints[j].Keywords)
+				switch {
+				case isKeywordSubset(ki, kj) && isKeywordSubset(kj, ki):
+					l.add(j, db.Fingerprints[j], LintWarning, fmt.Sprintf("与指纹 #%d 的关键字仅顺序或大小写不同", i))
+				case isKeywordSubset(ki, kj):
+					l.add(j, db.Fingerprints[j], LintWarning, fmt.Sprintf("关键字包含指纹 #%d 的全部关键字，规则冗余", i))
+				case isKeywordSubset(kj, ki):
+					l.add(i, db.Fingerprints[i], LintWarning, fmt.Sprintf("关键字包含指纹 #%d 的全部关键字，规则冗余", j))
+				}
+			}
+		}
+	}
+}
+
+// normalizedKeywords 归一化关键字用于近似比较
+func normalizedKeywords(keywords []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, keyword := range keywords {
+		set[strings.ToLower(strings.TrimSpace(keyword))] = true
+	}
+	return set
+}
+
+// isKeywordSubset 判断a是否为b的子集
+func isKeywordSubset(a, b map[string]bool) bool {
+	for keyword := range a {
+		if !b[keyword] {
+			return false
+		}
+	}
+	return true
+}
+
+// exprConditions 收集表达式中读取指定位置的条件
+func exprConditions(node exprNode, location string) []*exprCond {
+	switch n := node.(type) {
+	case *exprAnd:
+		return append(exprConditions(n.left, location), exprConditions(n.right, location)...)
+	case *exprOr:
+		return append(exprConditions(n.left, location), exprConditions(n.right, location)...)
+	case *exprNot:
+		return exprConditions(n.x, location)
+	case *exprCond:
+		if n.location == location {
+			return []*exprCond{n}
+		}
+	}
+	return nil
+}
+
+// exprPatterns 收集表达式中的正则
+func exprPatterns(node exprNode) []string {
+	switch n := node.(type) {
+	case *exprAnd:
+		return append(exprPatterns(n.left), exprPatterns(n.right)...)
+	case *exprOr:
+		return append(exprPatterns(n.left), exprPatterns(n.right)...)
+	case *exprNot:
+		return exprPatterns(n.x)
+	case *exprCond:
+		if n.re != nil {
+			return []string{n.value}
+		}
+	}
+	return nil
+}
+
+// backtrackRisk 检查嵌套量词和相邻的通配重复，返回风险说明
+func backtrackRisk(pattern string) string {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	return findBacktrackRisk(re, false)
+}
+
+// findBacktrackRisk 递归检查语法树，inRepeat表示当前位于无界量词内
+func findBacktrackRisk(re *syntax.Regexp, inRepeat bool) string {
+	unbounded := re.Op == syntax.OpStar || re.Op == syntax.OpPlus ||
+		(re.Op == syntax.OpRepeat && (re.Max == -1 || re.Max > 32))
+	if unbounded && inRepeat {
+		return "存在嵌套的量词"
+	}
+
+	if re.Op == syntax.OpConcat {
+		for i := 1; i < len(re.Sub); i++ {
+			if isWildcardRepeat(re.Sub[i-1]) && isWildcardRepeat(re.Sub[i]) {
+				return "存在相邻的通配重复"
+			}
+		}
+	}
+
+	for _, sub := range re.Sub {
+		if reason := findBacktrackRisk(sub, inRepeat || unbounded); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// isWildcardRepeat 判断是否为 .* 或 .+ 形式
+func isWildcardRepeat(re *syntax.Regexp) bool {
+	if re.Op != syntax.OpStar && re.Op != syntax.OpPlus {
+		return false
+	}
+	sub := re.Sub[0]
+	return sub.Op == syntax.OpAnyChar || sub.Op == syntax.OpAnyCharNotNL
+}
+
+// jsonHeaderArtifacts 只出现在JSON序列化的响应头中的片段：对象边界、键值分隔、相邻响应头之间和转义后的 & < >
+var jsonHeaderArtifacts = []string{`{"`, `"]}`, `":["`, `":"`, `"],"`, `\u0026`, `\u003c`, `\u003e`}
+
+// hasJSONHeaderArtifact 判断关键字是否依赖旧版JSON响应头视图
+// 原始格式中的双引号不转义，因此普通关键字中的 \" 也视为JSON片段，正则中的 \" 只是转义的双引号
+func hasJSONHeaderArtifact(keyword string, regex bool) bool {
+	if !regex && strings.Contains(keyword, `\"`) {
+		return true
+	}
+	for _, artifact := range jsonHeaderArtifacts {
+		if strings.Contains(keyword, artifact) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"strings"
+	"testing"
+)
+
+func TestLintJSONHeaderArtifacts(t *testing.T) {
+	keyword := func(keyword, format string) model.Fingerprint {
+		return model.Fingerprint{Method: methodKeyword, Location: locationHeader, Keywords: []string{keyword}, HeaderFormat: format}
+	}
+	tests := []struct {
+		name string
+		fp   model.Fingerprint
+		want bool
+	}{
+		{"key value separator", keyword(`"Server":["nginx`, ""), true},
+		{"between headers", keyword(`nginx"],"X-Powered-By`, ""), true},
+		{"object start", keyword(`{"Server`, ""), true},
+		{"object end", keyword(`nginx"]}`, ""), true},
+		{"flattened json", keyword(`"Server":"nginx"`, ""), true},
+		{"escaped ampersand", keyword(`path=/\u0026secure`, ""), true},
+		{"escaped angle bracket", keyword(`\u003cscript`, ""), true},
+		{"escaped quote", keyword(`filename=\"a.zip\"`, ""), true},
+		{"escaped quote in regex", model.Fingerprint{Method: methodRegular, Location: locationHeader, Keywords: []string{`filename=\"a`}}, false},
+		{"raw header", keyword(`Server: nginx`, ""), false},
+		{"legacy view enabled", keyword(`"Server":["nginx`, headerFormatJSON), false},
+		{"other location", model.Fingerprint{Method: methodKeyword, Location: locationBody, Keywords: []string{`{"a":"b"}`}}, false},
+		{"expr condition", model.Fingerprint{Method: methodExpr, Expr: `header="\"X-Jenkins\":[\"" && body="Jenkins"`}, true},
+		{"expr on body", model.Fingerprint{Method: methodExpr, Expr: `body="{\"a\":\"b\"}"`}, false},
+		{"expr with legacy view", model.Fingerprint{Method: methodExpr, Expr: `header="\"X-Jenkins\":[\""`, HeaderFormat: headerFormatJSON}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fp.CMS = "Test"
+			var got bool
+			for _, issue := range LintFingerprints(&model.FingerprintDB{Fingerprints: []model.Fingerprint{tt.fp}}) {
+				if issue.Level == LintError {
+					t.Fatalf("unexpected error: %s", issue.Message)
+				}
+				got = got || strings.Contains(issue.Message, "header_format: json")
+			}
+			if got != tt.want {
+				t.Errorf("warned = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeRulesAreNotDuplicates(t *testing.T) {
+	db := &model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		{CMS: "nacos", Method: methodKeyword, Location: locationBody, Keywords: []string{"Nacos"}, Path: "/nacos/"},
+		{CMS: "nacos", Method: methodKeyword, Location: locationBody, Keywords: []string{"Nacos"}},
+		{CMS: "spring", Method: methodKeyword, Location: locationBody, Keywords: []string{}, Path: "/actuator/health", StatusCode: 200},
+		{CMS: "spring", Method: methodKeyword, Location: locationBody, Keywords: []string{}, Path: "/env", StatusCode: 200},
+		{CMS: "spring", Method: methodKeyword, Location: locationBody, Keywords: []string{}, Path: "/env", StatusCode: 401},
+		{CMS: "nacos", Method: methodKeyword, Location: locationBody, Keywords: []string{"Nacos"}, Path: "/nacos/"},
+	}}
+
+	merged := MergeFingerprints(db)
+	if got := len(merged.Fingerprints); got != 5 {
+		t.Errorf("MergeFingerprints() kept %d rules, want 5", got)
+	}
+
+	issues := LintFingerprints(db)
+	if len(issues) != 1 || issues[0].Index != 5 || issues[0].Level != LintError {
+		t.Errorf("LintFingerprints() = %+v, want only #5 reported as duplicate of #0", issues)
+	}
+}
@@ -0,0 +1,161 @@
+package core
+
+import (
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"strings"
+)
+
+// 指纹重要程度，未设置时视为medium
+const (
+	ImportanceInfo     = "info"
+	ImportanceLow      = "low"
+	ImportanceMedium   = "medium"
+	ImportanceHigh     = "high"
+	ImportanceCritical = "critical"
+)
+
+// importanceLevels 重要程度对应的数值
+var importanceLevels = map[string]int{
+	ImportanceInfo:     0,
+	ImportanceLow:      1,
+	ImportanceMedium:   2,
+	ImportanceHigh:     3,
+	ImportanceCritical: 4,
+}
+
+// ImportanceLevel 返回重要程度的数值，空值按medium处理，未知值返回-1
+func ImportanceLevel(importance string) int {
+	if importance == "" {
+		importance = ImportanceMedium
+	}
+	if level, ok := importanceLevels[strings.ToLower(importance)]; ok {
+		return level
+	}
+	return -1
+}
+
+// 置信度相关常量
+const (
+	maxConfidence        = 100
+	DefaultMinConfidence = 50 // 未配置时计为命中的最低置信度
+)
+
+// validateConfidence 校验权重或置信度，有效范围为1-100，0表示使用默认值
+func validateConfidence(name string, value int) error {
+	if value < 0 || value > maxConfidence {
+		return fmt.Errorf("%s %d 超出范围 1-%d，0表示使用默认值", name, value, maxConfidence)
+	}
+	return nil
+}
+
+// validateMetadata 校验指纹元数据
+func validateMetadata(fp model.Fingerprint) error {
+	if ImportanceLevel(fp.Importance) < 0 {
+		return fmt.Errorf("未知的重要程度 %q", fp.Importance)
+	}
+	if err := validateConfidence("权重", fp.Weight); err != nil {
+		return err
+	}
+	for _, name := range append(append([]string(nil), fp.Implies...), fp.Excludes...) {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("implies 或 excludes 包含空的技术名称")
+		}
+	}
+	for _, name := range fp.Implies {
+		if containsValue(fp.Excludes, name) {
+			return fmt.Errorf("技术 %q 同时出现在 implies 和 excludes 中", name)
+		}
+	}
+	return nil
+}
+
+// ruleWeight 返回规则的权重，未设置时为100
+func ruleWeight(fp model.Fingerprint) int {
+	if fp.Weight == 0 {
+		return maxConfidence
+	}
+	return fp.Weight
+}
+
+// addConfidence 累加置信度，最大为100
+func addConfidence(tech *model.Technology, weight int) {
+	tech.Confidence += weight
+	if tech.Confidence > maxConfidence {
+		tech.Confidence = maxConfidence
+	}
+}
+
+// filterConfidence 保留置信度达到阈值的技术，阈值为0时使用默认值
+func filterConfidence(techs []model.Technology, minConfidence int) []model.Technology {
+	if minConfidence <= 0 {
+		minConfidence = DefaultMinConfidence
+	}
+	var filtered []model.Technology
+	for _, tech := range techs {
+		if tech.Confidence >= minConfidence {
+			filtered = append(filtered, tech)
+		}
+	}
+	return filtered
+}
+
+// newTechnology 根据命中的指纹生成技术信息
+func newTechnology(fp model.Fingerprint, version string) model.Technology {
+	return model.Technology{
+		Name:       fp.CMS,
+		Version:    version,
+		Category:   fp.Category,
+		Vendor:     fp.Vendor,
+		Product:    fp.Product,
+		Tags:       fp.Tags,
+		CPE:        fp.CPE,
+		Importance: fp.Importance,
+	}
+}
+
+// mergeTechnology 用同一技术的其他指纹补全缺失的版本和元数据
+func mergeTechnology(tech *model.Technology, fp model.Fingerprint, version string) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&tech.Version, version)
+	fill(&tech.Category, fp.Category)
+	fill(&tech.Vendor, fp.Vendor)
+	fill(&tech.Product, fp.Product)
+	fill(&tech.CPE, fp.CPE)
+	tech.Tags = mergeStrings(tech.Tags, fp.Tags)
+	if ImportanceLevel(fp.Importance) > ImportanceLevel(tech.Importance) {
+		tech.Importance = fp.Importance
+	}
+}
+
+// mergeStrings 合并两个字符串列表并去重
+func mergeStrings(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	merged := append(append([]string(nil), a...), b...)
+	seen := make(map[string]bool)
+	var list []string
+	for _, s := range merged {
+		if s != "" && !seen[s] {
+			seen[s] = true
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+// isFocus 判断技术列表中是否有达到重要程度阈值的技术
+func isFocus(techs []model.Technology, minImportance string) bool {
+	threshold := ImportanceLevel(minImportance)
+	for _, tech := range techs {
+		if ImportanceLevel(tech.Importance) >= threshold {
+			return true
+		}
+	}
+	return false
+}
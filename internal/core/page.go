@@ -0,0 +1,74 @@
+package core
+
+import (
+	"github.com/PuerkitoBio/goquery"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"regexp"
+	"strings"
+)
+
+// inlineGlobalRe 内联脚本中的全局变量赋值，如 window.__NUXT__= 或 var _wpemojiSettings =
+var inlineGlobalRe = regexp.MustCompile(`(?:\bwindow\.|\bvar\s+|\blet\s+|\bconst\s+)([A-Za-z_$][\w$]*)\s*=[^=]`)
+
+// extractPage 解析一次响应体，提取标题、meta、脚本、链接、图标、内联脚本全局变量和表单
+// 返回解析出的DOM供选择器规则复用
+func extractPage(resp *model.HTTPResponse) *goquery.Document {
+	doc, err := parseDocument(resp.Body)
+	if err != nil {
+		resp.Title = "Not found"
+		return doc
+	}
+	resp.Title = strings.TrimSpace(strings.ReplaceAll(doc.Find("title").Text(), "\n", ""))
+
+	doc.Find("meta[content]").Each(func(_ int, s *goquery.Selection) {
+		name := s.AttrOr("name", s.AttrOr("property", s.AttrOr("http-equiv", "")))
+		if name == "" {
+			return
+		}
+		content := strings.TrimSpace(s.AttrOr("content", ""))
+		resp.Metas = append(resp.Metas, name+": "+content)
+		if resp.Generator == "" && strings.EqualFold(name, "generator") {
+			resp.Generator = content
+		}
+	})
+
+	doc.Find("script").Each(func(_ int, s *goquery.Selection) {
+		if src, ok := s.Attr("src"); ok {
+			resp.Scripts = append(resp.Scripts, strings.TrimSpace(src))
+			return
+		}
+		for _, match := range inlineGlobalRe.FindAllStringSubmatch(s.Text(), -1) {
+			resp.Globals = append(resp.Globals, match[1])
+		}
+	})
+	resp.Globals = utils.RemoveDuplicates(resp.Globals)
+
+	doc.Find("link[href]").Each(func(_ int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		resp.Links = append(resp.Links, href)
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			switch rel {
+			case "icon", "apple-touch-icon", "apple-touch-icon-precomposed":
+				resp.Icons = append(resp.Icons, href)
+			case "manifest":
+				if resp.Manifest == "" {
+					resp.Manifest = href
+				}
+			}
+		}
+	})
+	doc.Find("form[action]").Each(func(_ int, s *goquery.Selection) {
+		resp.FormActions = append(resp.FormActions, strings.TrimSpace(s.AttrOr("action", "")))
+	})
+	return doc
+}
+
+// parseDocument 解析响应体，失败时同时返回空文档，选择器规则都不命中
+func parseDocument(body string) (*goquery.Document, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(body))
+	if err != nil {
+		doc, _ = goquery.NewDocumentFromReader(strings.NewReader(""))
+	}
+	return doc, err
+}
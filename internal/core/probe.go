@@ -0,0 +1,112 @@
+package core
+
+import (
+	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// probe 主动探测请求，请求相同的规则共用一次请求
+type probe struct {
+	path    string
+	method  string
+	headers map[string]string
+	body    string
+	rules   []*rule
+	favicon bool // 有规则需要匹配探测页面的图标哈希
+}
+
+// isActiveRule 判断指纹是否需要主动发起额外请求
+func isActiveRule(fp model.Fingerprint) bool {
+	return (fp.Path != "" && fp.Path != "/") ||
+		(fp.RequestMethod != "" && !strings.EqualFold(fp.RequestMethod, "GET")) ||
+		len(fp.RequestHeaders) > 0 || fp.RequestBody != ""
+}
+
+// usesFavicon 判断规则是否需要图标哈希
+func usesFavicon(r *rule) bool {
+	return r.fp.Method == methodFaviconHash || (r.expr != nil && exprUsesLocation(r.expr, locationIconHash))
+}
+
+// validateProbe 校验主动探测配置
+func validateProbe(fp model.Fingerprint) error {
+	if fp.Path != "" && !strings.HasPrefix(fp.Path, "/") {
+		return fmt.Errorf("探测路径 %q 必须以 / 开头", fp.Path)
+	}
+	switch strings.ToUpper(fp.RequestMethod) {
+	case "", "GET", "POST", "HEAD", "PUT", "OPTIONS":
+	default:
+		return fmt.Errorf("不支持的请求方法 %q", fp.RequestMethod)
+	}
+	return nil
+}
+
+// probeKey 生成探测请求的去重键
+func probeKey(fp model.Fingerprint) string {
+	names := make([]string, 0, len(fp.RequestHeaders))
+	for name := range fp.RequestHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	sb.WriteString(strings.ToUpper(fp.RequestMethod) + " " + fp.Path + "\x00")
+	for _, name := range names {
+		sb.WriteString(name + ": " + fp.RequestHeaders[name] + "\x00")
+	}
+	sb.WriteString(fp.RequestBody)
+	return sb.String()
+}
+
+// addProbeRule 将主动规则加入对应的探测请求
+func (e *Engine) addProbeRule(r *rule, probes map[string]*probe) {
+	key := probeKey(r.fp)
+	p, ok := probes[key]
+	if !ok {
+		method := strings.ToUpper(r.fp.RequestMethod)
+		if method == "" {
+			method = "GET"
+		}
+		p = &probe{path: r.fp.Path, method: method, headers: r.fp.RequestHeaders, body: r.fp.RequestBody}
+		probes[key] = p
+		e.probes = append(e.probes, p)
+	}
+	p.rules = append(p.rules, r)
+	p.favicon = p.favicon || usesFavicon(r)
+}
+
+// matchProbes 对目标主机发起主动探测并匹配探测规则，每个主机只探测一次
+func (s *Scanner) matchProbes(engine *Engine, urlStr string) []ruleMatch {
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	baseURL := u.Scheme + "://" + u.Host
+	if _, loaded := s.probedHosts.LoadOrStore(baseURL, true); loaded {
+		return nil
+	}
+
+	var matches []ruleMatch
+	for _, p := range engine.probes {
+		resp, doc, err := s.httpClient.doProbe(baseURL+p.path, p.method, p.headers, p.body)
+		if err != nil {
+			continue
+		}
+		// 只在需要时请求探测页面声明的图标
+		if p.favicon {
+			resp.Favicons = s.httpClient.getFavicons(resp)
+			resp.FaviconHash = firstFaviconHash(resp.Favicons)
+		}
+		view := newResponseView(engine, resp)
+		view.path = p.path
+		view.doc = doc
+		for _, r := range p.rules {
+			if s.matchFingerprint(r, view) {
+				matches = append(matches, ruleMatch{rule: r, view: view})
+			}
+		}
+	}
+	return matches
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMatchProbesFavicon(t *testing.T) {
+	icon := []byte("\x89PNG admin icon")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/admin/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><link rel="icon" href="/admin/icon.png"></head></html>`))
+	})
+	mux.HandleFunc("/admin/icon.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(icon)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	hash := utils.FaviconHashes("", icon).MMH3
+	s := newTestScanner(t, &model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		{CMS: "Admin", Method: "faviconhash", Location: "body", Path: "/admin/", Keywords: []string{hash}},
+		{CMS: "AdminExpr", Method: methodExpr, Expr: `icon_hash="` + hash + `"`, Path: "/admin/"},
+		{CMS: "Other", Method: "faviconhash", Location: "body", Path: "/admin/", Keywords: []string{"123"}},
+	}})
+	s.httpClient = NewHTTPClient("")
+
+	var names []string
+	for _, m := range s.matchProbes(s.engine.Load(), server.URL) {
+		names = append(names, m.rule.fp.CMS)
+	}
+	if len(names) != 2 || names[0] != "Admin" || names[1] != "AdminExpr" {
+		t.Errorf("matchProbes() = %v, want [Admin AdminExpr]", names)
+	}
+}
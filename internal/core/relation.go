@@ -0,0 +1,85 @@
+package core
+
+import "github.com/kN6jq/fingerScan/internal/model"
+
+// techRelation 同一技术全部规则合并后的元数据及推断、排除关系
+type techRelation struct {
+	tech     model.Technology // 被推断出时使用的技术信息
+	implies  []string
+	excludes []string
+}
+
+// addRelation 将规则的元数据和推断、排除关系合并到所属技术
+func (e *Engine) addRelation(fp model.Fingerprint) {
+	rel, ok := e.relations[fp.CMS]
+	if !ok {
+		rel = &techRelation{tech: newTechnology(fp, "")}
+		e.relations[fp.CMS] = rel
+	} else {
+		mergeTechnology(&rel.tech, fp, "")
+	}
+	rel.implies = mergeStrings(rel.implies, fp.Implies)
+	rel.excludes = mergeStrings(rel.excludes, fp.Excludes)
+}
+
+// applyRelations 按推断和排除关系扩展并裁剪识别结果
+// 直接命中的技术按顺序排除其他技术，再依次加入被推断的技术，被排除的技术不会被推断出
+func (e *Engine) applyRelations(techs []model.Technology) []model.Technology {
+	removed := make(map[string]bool)
+	for _, tech := range techs {
+		if removed[tech.Name] {
+			continue
+		}
+		if rel := e.relations[tech.Name]; rel != nil {
+			for _, name := range rel.excludes {
+				if name != tech.Name {
+					removed[name] = true
+				}
+			}
+		}
+	}
+
+	var result []model.Technology
+	positions := make(map[string]int)
+	for _, tech := range techs {
+		if !removed[tech.Name] {
+			positions[tech.Name] = len(result)
+			result = append(result, tech)
+		}
+	}
+
+	// 推断出的技术追加在末尾，继续参与推断
+	for i := 0; i < len(result); i++ {
+		from := result[i]
+		rel := e.relations[from.Name]
+		if rel == nil {
+			continue
+		}
+		for _, name := range rel.implies {
+			if removed[name] || name == from.Name {
+				continue
+			}
+			if pos, ok := positions[name]; ok {
+				if result[pos].Inferred {
+					result[pos].ImpliedBy = mergeStrings(result[pos].ImpliedBy, []string{from.Name})
+				}
+				continue
+			}
+			positions[name] = len(result)
+			result = append(result, e.inferTechnology(name, from))
+		}
+	}
+	return result
+}
+
+// inferTechnology 生成被推断出的技术，置信度沿用推断来源
+func (e *Engine) inferTechnology(name string, from model.Technology) model.Technology {
+	tech := model.Technology{Name: name}
+	if rel := e.relations[name]; rel != nil {
+		tech = rel.tech
+	}
+	tech.Confidence = from.Confidence
+	tech.Inferred = true
+	tech.ImpliedBy = []string{from.Name}
+	return tech
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"fmt"
+	"github.com/kN6jq/fingerScan/pkg/logger"
+	"os"
+	"time"
+)
+
+// defaultWatchInterval 默认的指纹文件检查间隔
+const defaultWatchInterval = 5 * time.Second
+
+// Reload 重新加载外部指纹文件并替换当前引擎
+// 外部指纹中存在无效规则时拒绝本次加载，继续使用原有指纹
+func (s *Scanner) Reload() error {
+	external, err := LoadFingerprintFiles(s.config.FingerprintFiles)
+	if err != nil {
+		return err
+	}
+	if errs := NewEngine(external).Errors; len(errs) > 0 {
+		return fmt.Errorf("外部指纹存在%d条无效规则: %v", len(errs), errs[0])
+	}
+
+	db := MergeFingerprints(external)
+	if !s.config.ReplaceFingerprints {
+		embedded, err := LoadFingerprints()
+		if err != nil {
+			return err
+		}
+		db = MergeFingerprints(embedded, external)
+	}
+
+	s.engine.Store(NewEngine(db))
+	return nil
+}
+
+// fileState 指纹文件的修改时间和大小
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// fingerprintFileStates 获取所有外部指纹文件的当前状态
+func fingerprintFileStates(paths []string) (map[string]fileState, error) {
+	states := make(map[string]fileState)
+	for _, path := range paths {
+		files, err := CollectFingerprintFiles(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			info, err := os.Stat(file)
+			if err != nil {
+				return nil, err
+			}
+			states[file] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return states, nil
+}
+
+// sameFileStates 判断两次检查之间指纹文件是否没有变化
+func sameFileStates(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for file, state := range a {
+		other, ok := b[file]
+		if !ok || !other.modTime.Equal(state.modTime) || other.size != state.size {
+			return false
+		}
+	}
+	return true
+}
+
+// WatchFingerprints 定期检查外部指纹文件，文件变化后热加载指纹
+// 返回的函数用于停止监视
+func (s *Scanner) WatchFingerprints(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+
+	last, err := fingerprintFileStates(s.config.FingerprintFiles)
+	if err != nil {
+		logger.Warning("读取指纹文件状态失败: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			states, err := fingerprintFileStates(s.config.FingerprintFiles)
+			if err != nil {
+				// 文件可能正在被编辑器替换，下次再检查
+				continue
+			}
+			if sameFileStates(last, states) {
+				continue
+			}
+			last = states
+
+			if err := s.Reload(); err != nil {
+				s.logReload(logger.Warning, "指纹热加载被拒绝，继续使用原有指纹: %v", err)
+				continue
+			}
+			s.logReload(logger.Info, "指纹热加载完成，共%d条规则", len(s.engine.Load().rules))
+		}
+	}()
+
+	return func() { close(done) }
+}
+
+// logReload 非静默模式下记录热加载事件
+func (s *Scanner) logReload(log func(format string, args ...interface{}), format string, args ...interface{}) {
+	if !s.config.Silent {
+		log(format, args...)
+	}
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// rulesFile 生成只含一条header关键字规则的指纹文件
+func rulesFile(cms, pattern string) string {
+	return `{"fingerprint": [{"cms": "` + cms + `", "method": "regular", "location": "header", "keyword": ["` + pattern + `"]}]}`
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "rules.json")
+	writeFiles(t, dir, map[string]string{"rules.json": rulesFile("Nginx", "nginx")})
+
+	s := &Scanner{config: ScanConfig{FingerprintFiles: []string{file}, ReplaceFingerprints: true, Silent: true}}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	resp := newTestResponse(200, map[string][]string{"Server": {"nginx"}, "X-Powered-By": {"PHP/8.2"}}, "")
+	if got := identify(s, resp); !reflect.DeepEqual(got, []string{"Nginx"}) {
+		t.Fatalf("identify() = %v, want [Nginx]", got)
+	}
+
+	// 修改后的规则替换整个引擎
+	first := s.engine.Load()
+	writeFiles(t, dir, map[string]string{"rules.json": rulesFile("PHP", "PHP/8")})
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	if s.engine.Load() == first {
+		t.Fatal("Reload() did not swap the engine")
+	}
+	if got := identify(s, resp); !reflect.DeepEqual(got, []string{"PHP"}) {
+		t.Fatalf("identify() after reload = %v, want [PHP]", got)
+	}
+
+	// 无效的正则被拒绝，继续使用原有引擎
+	second := s.engine.Load()
+	writeFiles(t, dir, map[string]string{"rules.json": rulesFile("Broken", "(")})
+	if err := s.Reload(); err == nil {
+		t.Fatal("Reload() accepted an invalid regex")
+	}
+	if s.engine.Load() != second {
+		t.Fatal("Reload() replaced the engine after an invalid edit")
+	}
+	if got := identify(s, resp); !reflect.DeepEqual(got, []string{"PHP"}) {
+		t.Errorf("identify() after rejected reload = %v, want [PHP]", got)
+	}
+}
+
+func TestWatchFingerprints(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "rules.json")
+	writeFiles(t, dir, map[string]string{"rules.json": rulesFile("Nginx", "nginx")})
+
+	s := &Scanner{config: ScanConfig{FingerprintFiles: []string{file}, ReplaceFingerprints: true, Silent: true}}
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	first := s.engine.Load()
+
+	stop := s.WatchFingerprints(10 * time.Millisecond)
+	writeFiles(t, dir, map[string]string{"rules.json": rulesFile("Apache", "Apache")})
+	deadline := time.Now().Add(2 * time.Second)
+	for s.engine.Load() == first {
+		if time.Now().After(deadline) {
+			t.Fatal("WatchFingerprints() did not reload the changed file")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 停止后不再加载
+	stop()
+	stopped := s.engine.Load()
+	writeFiles(t, dir, map[string]string{"rules.json": rulesFile("Tomcat", "Apache-Coyote")})
+	time.Sleep(100 * time.Millisecond)
+	if s.engine.Load() != stopped {
+		t.Error("WatchFingerprints() reloaded after stop")
+	}
+}
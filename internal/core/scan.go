@@ -2,22 +2,32 @@
 package core
 
 import (
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/kN6jq/fingerScan/internal/model"
 	"github.com/kN6jq/fingerScan/internal/utils"
+	"github.com/kN6jq/fingerScan/pkg/logger"
 	"github.com/panjf2000/ants/v2"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // Scanner 指纹扫描器
 type Scanner struct {
-	urlQueue     *Queue
-	httpClient   *HTTPClient
-	fingerprints *model.FingerprintDB
-	Results      *ScanResults
-	workerPool   *ants.Pool
-	wg           sync.WaitGroup
-	config       ScanConfig
+	urlQueue   *Queue
+	httpClient *HTTPClient
+	engine     atomic.Pointer[Engine] // 当前使用的指纹引擎，热加载时整体替换
+	Results    *ScanResults
+	workerPool *ants.Pool
+	wg         sync.WaitGroup
+	config     ScanConfig
+
+	probedHosts sync.Map    // 已主动探测过的主机
+	ruleHits    ruleCounter // 各规则命中的主机数
+	scripts     scriptCache // 已抓取的同源脚本
 }
 
 // ScanConfig 扫描配置
@@ -26,6 +36,26 @@ type ScanConfig struct {
 	OutputFile  string
 	ProxyURL    string
 	Silent      bool // 是否禁用输出
+
+	FingerprintFiles    []string // 外部指纹文件或目录
+	ReplaceFingerprints bool     // 外部指纹替换内置指纹而非追加
+
+	WatchFingerprints bool          // 监视外部指纹文件并在修改后热加载
+	WatchInterval     time.Duration // 检查指纹文件修改的间隔，默认5秒
+
+	Compact bool // 控制台输出不显示命中证据
+	Active  bool // 对每个主机发起主动探测请求
+
+	FetchScripts    int   // 每个页面抓取的同源脚本数量上限，0时不抓取
+	MaxScriptSize   int64 // 单个脚本的最大读取字节数，默认2MB
+	ProbeSourceMaps bool  // 脚本没有声明sourceMappingURL时也尝试请求同名的.map文件
+
+	MinConfidence int      // 计为命中的最低置信度，0时使用默认值50
+	MinImportance string   // 列入重点资产的最低重要程度，默认medium
+	Categories    []string // 只输出包含这些分类的结果
+	Tags          []string // 只输出包含这些标签的结果
+
+	RuleStatsFile string // 保存规则命中统计的JSON文件，为 - 时打印统计表
 }
 
 // ScanResults 扫描结果
@@ -37,25 +67,45 @@ type ScanResults struct {
 
 // NewScanner 创建新的扫描器实例
 func NewScanner(urls []string, config ScanConfig) (*Scanner, error) {
-	fingerprints, err := LoadFingerprints()
+	fingerprints, err := LoadFingerprintsWithFiles(config.FingerprintFiles, config.ReplaceFingerprints)
 	if err != nil {
 		return nil, err
 	}
 
+	if ImportanceLevel(config.MinImportance) < 0 {
+		return nil, fmt.Errorf("未知的重要程度 %q", config.MinImportance)
+	}
+	if config.OutputFile != "" {
+		if err := utils.CheckOutputFormat(config.OutputFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateConfidence("最低置信度", config.MinConfidence); err != nil {
+		return nil, err
+	}
+
+	engine := NewEngine(fingerprints)
+	if !config.Silent {
+		for _, err := range engine.Errors {
+			logger.Warning("跳过无效指纹: %v", err)
+		}
+	}
+
 	pool, err := ants.NewPool(config.ThreadCount)
 	if err != nil {
 		return nil, err
 	}
 
 	scanner := &Scanner{
-		urlQueue:     NewQueue(),
-		httpClient:   NewHTTPClient(config.ProxyURL),
-		fingerprints: fingerprints,
-		Results:      &ScanResults{},
-		workerPool:   pool,
-		config:       config,
+		urlQueue:   NewQueue(),
+		httpClient: NewHTTPClient(config.ProxyURL),
+		Results:    &ScanResults{},
+		workerPool: pool,
+		config:     config,
 	}
 
+	scanner.engine.Store(engine)
+
 	// 初始化URL队列
 	for _, url := range urls {
 		scanner.urlQueue.Push([]string{url, "0"})
@@ -68,6 +118,11 @@ func NewScanner(urls []string, config ScanConfig) (*Scanner, error) {
 func (s *Scanner) Start() error {
 	defer s.workerPool.Release()
 
+	if s.config.WatchFingerprints && len(s.config.FingerprintFiles) > 0 {
+		stop := s.WatchFingerprints(s.config.WatchInterval)
+		defer stop()
+	}
+
 	// 提交扫描任务
 	for s.urlQueue.Len() > 0 {
 		s.wg.Add(1)
@@ -83,8 +138,7 @@ func (s *Scanner) Start() error {
 	s.wg.Wait()
 
 	// 输出结果
-	//s.outputResults()
-	return nil
+	return s.outputResults()
 }
 
 // scanWorker 扫描工作协程
@@ -96,11 +150,17 @@ func (s *Scanner) scanWorker() {
 			continue
 		}
 
-		resp, err := s.httpClient.DoRequest(urls[0])
+		resp, doc, err := s.httpClient.doRequest(urls[0])
 		if err != nil {
 			continue
 		}
 
+		// 抓取同源脚本并分析其中的source map和npm包
+		var packages []model.Technology
+		if s.config.FetchScripts > 0 {
+			packages = s.analyzeBundles(resp, s.fetchScripts(resp))
+		}
+
 		// 处理JS跳转
 		if urls[1] == "0" {
 			for _, jsURL := range resp.JSURLs {
@@ -109,20 +169,31 @@ func (s *Scanner) scanWorker() {
 		}
 
 		// 识别CMS
-		cms := s.identifyCMS(resp)
+		techs := s.identifyCMS(resp, doc)
+		var cms []string
+		for _, tech := range techs {
+			cms = append(cms, tech.Name)
+		}
 		result := model.ScanResult{
-			URL:        resp.URL,
-			CMS:        strings.Join(cms, ","),
-			Server:     resp.Server,
-			StatusCode: resp.StatusCode,
-			Length:     resp.Length,
-			Title:      resp.Title,
+			URL:          resp.URL,
+			CMS:          strings.Join(cms, ","),
+			Server:       resp.Server,
+			StatusCode:   resp.StatusCode,
+			Length:       resp.Length,
+			Title:        resp.Title,
+			Generator:    resp.Generator,
+			Technologies: techs,
+
+			SourceMapExposed: len(resp.SourceMaps) > 0,
+			SourceMaps:       resp.SourceMaps,
+			Favicons:         resp.Favicons,
+			Packages:         removeIdentified(packages, techs),
 		}
 
 		// 保存结果
 		s.Results.Lock()
 		s.Results.All = append(s.Results.All, result)
-		if len(cms) > 0 {
+		if isFocus(techs, s.config.MinImportance) {
 			s.Results.Focus = append(s.Results.Focus, result)
 		}
 		s.Results.Unlock()
@@ -135,49 +206,183 @@ func (s *Scanner) scanWorker() {
 	}
 }
 
-// identifyCMS 识别CMS
-func (s *Scanner) identifyCMS(resp *model.HTTPResponse) []string {
-	var cms []string
-	for _, fp := range s.fingerprints.Fingerprints {
-		if s.matchFingerprint(fp, resp) {
-			cms = append(cms, fp.CMS)
+// identifyCMS 识别CMS及其版本，开启主动探测时同时匹配探测请求的响应
+// 识别结果按指纹的implies和excludes扩展和裁剪，doc为extractPage解析出的DOM，为nil时按需解析
+func (s *Scanner) identifyCMS(resp *model.HTTPResponse, doc *goquery.Document) []model.Technology {
+	// 每个响应只使用同一个引擎，热加载不影响正在匹配的响应
+	engine := s.engine.Load()
+	view := newResponseView(engine, resp)
+	view.doc = doc
+	matches := s.matchResponse(view)
+	if s.config.Active {
+		matches = append(matches, s.matchProbes(engine, resp.URL)...)
+	}
+	s.ruleHits.record(resp.URL, matches)
+	return engine.applyRelations(s.buildTechnologies(matches))
+}
+
+// ruleMatch 命中的规则及其所在的响应视图
+type ruleMatch struct {
+	rule *rule
+	view *responseView
+}
+
+// matchResponse 匹配首页响应上的被动规则
+func (s *Scanner) matchResponse(view *responseView) []ruleMatch {
+	var matches []ruleMatch
+	scanned := make(map[string]bool)
+	for _, group := range view.engine.groups {
+		// keyword规则按位置单次扫描，一次得到所有命中的规则
+		if group.method == methodKeyword && !isExactLocation(group.location) {
+			if !scanned[group.location] {
+				scanned[group.location] = true
+				idx := view.engine.keywords[group.location]
+				for _, r := range idx.candidates(view.keywordHits(group.location)) {
+					if matchStatus(r, view) {
+						matches = append(matches, ruleMatch{rule: r, view: view})
+					}
+				}
+			}
+			continue
+		}
+		for _, r := range group.rules {
+			if s.matchFingerprint(r, view) {
+				matches = append(matches, ruleMatch{rule: r, view: view})
+			}
 		}
 	}
-	return utils.RemoveDuplicates(cms)
+	return matches
 }
 
-// matchFingerprint 匹配指纹
-func (s *Scanner) matchFingerprint(fp model.Fingerprint, resp *model.HTTPResponse) bool {
-	var content string
-	switch fp.Location {
-	case "body":
-		content = resp.Body
-		if fp.Method == "faviconhash" && len(fp.Keywords) > 0 {
-			return resp.FaviconHash == fp.Keywords[0]
-		}
-	case "header":
-		content = utils.HeadersToString(resp.Headers)
-	case "title":
-		content = resp.Title
+// buildTechnologies 将命中的规则合并为技术列表
+// 同一技术的多条规则合并为一项，取第一个提取到的版本号
+func (s *Scanner) buildTechnologies(matches []ruleMatch) []model.Technology {
+	var techs []model.Technology
+	positions := make(map[string]int)
+	for _, m := range matches {
+		r := m.rule
+		version := s.extractVersion(r, m.view)
+		pos, ok := positions[r.fp.CMS]
+		if ok {
+			mergeTechnology(&techs[pos], r.fp, version)
+		} else {
+			pos = len(techs)
+			positions[r.fp.CMS] = pos
+			techs = append(techs, newTechnology(r.fp, version))
+		}
+		techs[pos].Evidence = append(techs[pos].Evidence, s.collectEvidence(r, m.view)...)
+		addConfidence(&techs[pos], ruleWeight(r.fp))
+	}
+	return filterConfidence(techs, s.config.MinConfidence)
+}
+
+// extractVersion 从命中的规则中提取版本号
+func (s *Scanner) extractVersion(r *rule, view *responseView) string {
+	if r.fp.VersionAttr != "" {
+		return selectorVersion(r, view)
+	}
+
+	var re *regexp.Regexp
+	group := 1
+	switch {
+	case r.version != nil:
+		re = r.version
+	case r.fp.VersionGroup > 0:
+		re, group = r.patterns[0], r.fp.VersionGroup
 	default:
+		return ""
+	}
+
+	content, ok := view.content(r.versionLocation())
+	if !ok {
+		return ""
+	}
+	if match := re.FindStringSubmatch(content); len(match) > group {
+		return strings.TrimSpace(match[group])
+	}
+	return ""
+}
+
+// selectorVersion 从第一个选择器命中的第一个元素的属性中提取版本号
+func selectorVersion(r *rule, view *responseView) string {
+	value, ok := view.document().FindMatcher(r.selectors[0]).First().Attr(r.fp.VersionAttr)
+	if !ok {
+		return ""
+	}
+	if r.version != nil {
+		match := r.version.FindStringSubmatch(value)
+		if len(match) < 2 {
+			return ""
+		}
+		value = match[1]
+	}
+	return strings.TrimSpace(value)
+}
+
+// matchFingerprint 匹配指纹
+func (s *Scanner) matchFingerprint(r *rule, view *responseView) bool {
+	if !matchStatus(r, view) {
 		return false
 	}
+	if r.statusOnly {
+		return true
+	}
+
+	switch r.fp.Method {
+	case methodFaviconHash:
+		_, ok := matchedFavicon(view.resp, r.fp.HashAlgorithm, r.fp.Keywords[0])
+		return ok
+	case methodExpr:
+		return r.expr.eval(view)
+	case methodSelector:
+		return matchSelectors(view.document(), r.selectors)
+	}
 
-	switch fp.Method {
-	case "keyword":
-		return utils.ContainsAllKeywords(content, fp.Keywords)
-	case "regular":
-		return utils.MatchesAllPatterns(content, fp.Keywords)
+	content, ok := view.content(r.location)
+	if !ok {
+		return false
+	}
+
+	switch r.fp.Method {
+	case methodKeyword:
+		if isExactLocation(r.location) {
+			return matchValues(view.values(r.location), r.fp.Keywords)
+		}
+		// 主动规则没有建立自动机
+		if r.keywordIDs == nil {
+			return utils.ContainsAllKeywords(content, r.fp.Keywords)
+		}
+		return matchKeywordIDs(view.keywordHits(r.location), r.keywordIDs)
+	case methodRegular:
+		if isExactLocation(r.location) {
+			return matchValuePatterns(view.values(r.location), r.patterns)
+		}
+		return matchPatterns(content, r.patterns)
 	}
 	return false
 }
 
-// outputResults 输出扫描结果
-func (s *Scanner) outputResults() {
-	utils.PrintColoredResults(s.Results.Focus)
+// matchStatus 检查响应状态码是否符合规则要求
+func matchStatus(r *rule, view *responseView) bool {
+	return r.fp.StatusCode == 0 || r.fp.StatusCode == view.resp.StatusCode
+}
+
+// outputResults 输出按分类分组的重点资产并保存结果和规则命中统计
+func (s *Scanner) outputResults() error {
+	focus := utils.FilterResults(s.Results.Focus, s.config.Categories, s.config.Tags)
+	if !s.config.Silent && len(focus) > 0 {
+		utils.PrintColoredResults(focus)
+	}
 	if s.config.OutputFile != "" {
-		utils.SaveResults(s.config.OutputFile, s.Results.All)
+		all := utils.FilterResults(s.Results.All, s.config.Categories, s.config.Tags)
+		if err := utils.SaveResults(s.config.OutputFile, all); err != nil {
+			return err
+		}
+	}
+	if s.config.RuleStatsFile != "" {
+		return s.outputRuleStats()
 	}
+	return nil
 }
 
 // printProgress 打印扫描进度
@@ -187,4 +392,7 @@ func (s *Scanner) printProgress(result model.ScanResult) {
 	} else {
 		utils.PrintResult(result)
 	}
+	if !s.config.Compact {
+		utils.PrintEvidence(result)
+	}
 }
@@ -0,0 +1,47 @@
+package core
+
+import "testing"
+
+func TestNewScannerMinConfidence(t *testing.T) {
+	tests := []struct {
+		confidence int
+		wantErr    bool
+	}{
+		{-1, true},
+		{0, false},
+		{1, false},
+		{100, false},
+		{101, true},
+	}
+	for _, tt := range tests {
+		s, err := NewScanner(nil, ScanConfig{ThreadCount: 1, MinConfidence: tt.confidence, Silent: true})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewScanner(MinConfidence: %d) error = %v, wantErr %v", tt.confidence, err, tt.wantErr)
+		}
+		if s != nil {
+			s.workerPool.Release()
+		}
+	}
+}
+
+func TestNewScannerOutputFormat(t *testing.T) {
+	tests := []struct {
+		file    string
+		wantErr bool
+	}{
+		{"", false},
+		{"out.json", false},
+		{"OUT.XLSX", false},
+		{"out.csv", true},
+		{"out", true},
+	}
+	for _, tt := range tests {
+		s, err := NewScanner(nil, ScanConfig{ThreadCount: 1, OutputFile: tt.file, Silent: true})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("NewScanner(OutputFile: %q) error = %v, wantErr %v", tt.file, err, tt.wantErr)
+		}
+		if s != nil {
+			s.workerPool.Release()
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"encoding/base64"
+	"github.com/kN6jq/fingerScan/internal/model"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// packageConfidence 从打包产物中识别出的npm包只依据模块路径，置信度低于默认阈值
+const packageConfidence = 30
+
+// locationSourceMap 证据中表示命中source map内容的位置
+const locationSourceMap = "sourcemap"
+
+var (
+	// sourceMappingRe 脚本中声明的source map地址
+	sourceMappingRe = regexp.MustCompile(`//[#@]\s*sourceMappingURL=(\S+)`)
+	// modulePathRe webpack模块路径和source map的sources中的npm包名
+	modulePathRe = regexp.MustCompile(`node_modules[\\/]+((?:@[\w.-]+[\\/]+)?[\w.-]+)`)
+	// npmChunkRe 按npm包拆分的chunk名称，如 "npm.element-ui"
+	npmChunkRe = regexp.MustCompile(`["']npm\.([\w.-]+)["']`)
+	// pathSeparatorRe 模块路径中的分隔符，字符串中的反斜杠可能被转义
+	pathSeparatorRe = regexp.MustCompile(`[\\/]+`)
+)
+
+// packageRef 打包产物中引用的npm包
+type packageRef struct {
+	name    string
+	pattern string // 命中的模块路径或chunk名称
+}
+
+// analyzeBundles 分析抓取到的脚本，记录可访问的source map
+// 脚本和source map中引用的npm包作为低置信度的技术返回，不计入CMS识别结果
+func (s *Scanner) analyzeBundles(resp *model.HTTPResponse, bundles []bundle) []model.Technology {
+	var techs []model.Technology
+	seen := make(map[string]bool)
+	add := func(refs []packageRef, location, source string) {
+		for _, ref := range refs {
+			if seen[ref.name] {
+				continue
+			}
+			seen[ref.name] = true
+			techs = append(techs, model.Technology{
+				Name:       ref.name,
+				Category:   "JavaScript库",
+				Tags:       []string{"npm"},
+				Importance: ImportanceInfo,
+				Confidence: packageConfidence,
+				Evidence: []model.Evidence{{
+					Rule:     "bundle",
+					Location: location,
+					Pattern:  ref.pattern,
+					Snippet:  source,
+				}},
+			})
+		}
+	}
+
+	for _, b := range bundles {
+		add(packageRefs(b.body), locationJS, b.url)
+		mapURL, content := s.fetchSourceMap(b)
+		if mapURL == "" {
+			continue
+		}
+		resp.SourceMaps = append(resp.SourceMaps, mapURL)
+		add(packageRefs(content), locationSourceMap, mapURL)
+	}
+	return techs
+}
+
+// fetchSourceMap 获取脚本对应的source map，返回地址和内容，无法访问时地址为空
+// 脚本没有声明sourceMappingURL时，开启ProbeSourceMaps才尝试同名的.map文件，内联的data URI以脚本地址表示
+// source map通常很大且只在分析时使用，不放入脚本缓存
+func (s *Scanner) fetchSourceMap(b bundle) (string, string) {
+	base, err := url.Parse(b.url)
+	if err != nil {
+		return "", ""
+	}
+
+	mapURL := *base
+	mapURL.Path += ".map"
+	mapURL.RawQuery = ""
+	if matches := sourceMappingRe.FindAllStringSubmatch(b.body, -1); len(matches) > 0 {
+		ref := matches[len(matches)-1][1]
+		if strings.HasPrefix(ref, "data:") {
+			content := decodeDataURI(ref)
+			if !isSourceMap(content) {
+				return "", ""
+			}
+			return b.url, content
+		}
+		u, err := base.Parse(ref)
+		if err != nil || u.Scheme != base.Scheme || u.Host != base.Host {
+			return "", ""
+		}
+		mapURL = *u
+	} else if !s.config.ProbeSourceMaps {
+		return "", ""
+	}
+	mapURL.Fragment = ""
+
+	content, err := s.httpClient.FetchScript(mapURL.String(), s.maxScriptSize())
+	if err != nil || !isSourceMap(content) {
+		return "", ""
+	}
+	return mapURL.String(), content
+}
+
+// isSourceMap 判断内容是否为source map，读取大小受限时内容可能被截断
+func isSourceMap(content string) bool {
+	content = strings.TrimSpace(content)
+	return strings.HasPrefix(content, "{") &&
+		(strings.Contains(content, `"mappings"`) || strings.Contains(content, `"sources"`))
+}
+
+// decodeDataURI 解码data URI中的内容，格式错误时返回空
+func decodeDataURI(uri string) string {
+	i := strings.IndexByte(uri, ',')
+	if i < 0 {
+		return ""
+	}
+	meta, data := uri[:i], uri[i+1:]
+	if strings.HasSuffix(meta, ";base64") {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return ""
+		}
+		return string(decoded)
+	}
+	decoded, err := url.PathUnescape(data)
+	if err != nil {
+		return ""
+	}
+	return decoded
+}
+
+// packageRefs 按出现顺序提取模块路径和chunk名称中的npm包，同一个包只保留第一次出现
+func packageRefs(content string) []packageRef {
+	var refs []packageRef
+	seen := make(map[string]bool)
+	add := func(name, pattern string) {
+		name = strings.ToLower(name)
+		// .bin、.pnpm 等是包管理器的目录而不是包
+		if name == "" || name[0] == '.' || seen[name] {
+			return
+		}
+		seen[name] = true
+		refs = append(refs, packageRef{name: name, pattern: pattern})
+	}
+
+	for _, match := range modulePathRe.FindAllStringSubmatch(content, -1) {
+		name := pathSeparatorRe.ReplaceAllString(match[1], "/")
+		add(name, "node_modules/"+name)
+	}
+	for _, match := range npmChunkRe.FindAllStringSubmatch(content, -1) {
+		add(match[1], "npm."+match[1])
+	}
+	return refs
+}
+
+// removeIdentified 去掉已由指纹识别出的npm包
+func removeIdentified(packages, techs []model.Technology) []model.Technology {
+	var remaining []model.Technology
+	for _, pkg := range packages {
+		exists := false
+		for _, tech := range techs {
+			if strings.EqualFold(tech.Name, pkg.Name) {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			remaining = append(remaining, pkg)
+		}
+	}
+	return remaining
+}
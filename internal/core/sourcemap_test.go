@@ -0,0 +1,33 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackageRefs(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"module path", `"./node_modules/vue/dist/vue.js"`, []string{"vue"}},
+		{"scoped package", `"./node_modules/@babel/runtime/helpers/x.js"`, []string{"@babel/runtime"}},
+		{"escaped windows path", `"node_modules\\element-ui\\lib\\index.js"`, []string{"element-ui"}},
+		{"pnpm store", `node_modules/.pnpm/axios@1.0/node_modules/axios/index.js`, []string{"axios"}},
+		{"npm chunk", `{"npm.echarts":"abc"}`, []string{"echarts"}},
+		{"duplicate", `node_modules/vue/a.js node_modules/Vue/b.js`, []string{"vue"}},
+		{"none", `function(){}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, ref := range packageRefs(tt.content) {
+				got = append(got, ref.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("packageRefs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"github.com/kN6jq/fingerScan/internal/utils"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+const (
+	noisyRuleRate = 0.3 // 命中主机占比达到该值的规则视为可能误报
+	noisyMinHosts = 20  // 主机数达到该值后才判断误报，避免样本过少
+)
+
+// ruleCounter 规则命中计数器，按主机去重
+// JS跳转后的页面和主动探测的响应与首页属于同一主机，同一规则在一个主机上只计一次
+type ruleCounter struct {
+	sync.Mutex
+	hosts map[string]map[string]bool // 主机 -> 已命中的规则标识
+	hits  map[string]int             // 规则标识 -> 命中的主机数
+	cms   map[string]string          // 规则标识 -> CMS名称
+}
+
+// record 记录一个响应上命中的规则
+func (c *ruleCounter) record(urlStr string, matches []ruleMatch) {
+	c.Lock()
+	defer c.Unlock()
+	if c.hosts == nil {
+		c.hosts = make(map[string]map[string]bool)
+		c.hits = make(map[string]int)
+		c.cms = make(map[string]string)
+	}
+
+	host := statsHost(urlStr)
+	fired, ok := c.hosts[host]
+	if !ok {
+		fired = make(map[string]bool)
+		c.hosts[host] = fired
+	}
+	for _, m := range matches {
+		if fired[m.rule.id] {
+			continue
+		}
+		fired[m.rule.id] = true
+		c.hits[m.rule.id]++
+		c.cms[m.rule.id] = m.rule.fp.CMS
+	}
+}
+
+// statsHost 返回统计使用的主机，URL无法解析时使用原始URL
+func statsHost(urlStr string) string {
+	if u, err := url.Parse(urlStr); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return urlStr
+}
+
+// RuleStats 返回规则命中统计，包含当前指纹中从未命中的规则
+func (s *Scanner) RuleStats() model.RuleStats {
+	c := &s.ruleHits
+	c.Lock()
+	defer c.Unlock()
+
+	hosts := len(c.hosts)
+	stats := model.RuleStats{Hosts: hosts, Rules: []model.RuleStat{}}
+	seen := make(map[string]bool)
+	add := func(id, cms string, hits int) {
+		seen[id] = true
+		stat := model.RuleStat{Rule: id, CMS: cms, Hits: hits}
+		if hosts > 0 {
+			stat.Rate = float64(hits) / float64(hosts)
+		}
+		stat.Noisy = hosts >= noisyMinHosts && stat.Rate >= noisyRuleRate
+		stats.Rules = append(stats.Rules, stat)
+	}
+
+	for id, hits := range c.hits {
+		add(id, c.cms[id], hits)
+	}
+	if engine := s.engine.Load(); engine != nil {
+		for _, r := range engine.rules {
+			if !seen[r.id] {
+				add(r.id, r.fp.CMS, 0)
+			}
+		}
+	}
+
+	sort.Slice(stats.Rules, func(i, j int) bool {
+		if stats.Rules[i].Hits != stats.Rules[j].Hits {
+			return stats.Rules[i].Hits > stats.Rules[j].Hits
+		}
+		return stats.Rules[i].Rule < stats.Rules[j].Rule
+	})
+	return stats
+}
+
+// outputRuleStats 打印规则命中统计表，或保存为JSON文件
+func (s *Scanner) outputRuleStats() error {
+	stats := s.RuleStats()
+	if s.config.RuleStatsFile == "-" {
+		utils.PrintRuleStats(stats)
+		return nil
+	}
+	return utils.SaveRuleStats(s.config.RuleStatsFile, stats)
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"testing"
+)
+
+func TestRuleStatsCountHosts(t *testing.T) {
+	s := newTestScanner(t, &model.FingerprintDB{Fingerprints: []model.Fingerprint{
+		{CMS: "Nginx", Method: methodKeyword, Location: locationHeader, Keywords: []string{"nginx"}},
+		{CMS: "Never", Method: methodKeyword, Location: locationBody, Keywords: []string{"never"}},
+	}})
+	nginx := s.engine.Load().rules[0]
+	hit := []ruleMatch{{rule: nginx}}
+
+	// 首页、JS跳转后的页面和探测响应属于同一主机
+	s.ruleHits.record("http://a.example.com/", hit)
+	s.ruleHits.record("http://a.example.com/login.html", hit)
+	s.ruleHits.record("http://a.example.com/", append(hit, hit...))
+	s.ruleHits.record("https://b.example.com:8443/", nil)
+
+	stats := s.RuleStats()
+	if stats.Hosts != 2 {
+		t.Errorf("Hosts = %d, want 2", stats.Hosts)
+	}
+	want := []model.RuleStat{
+		{Rule: "Nginx#0", CMS: "Nginx", Hits: 1, Rate: 0.5},
+		{Rule: "Never#1", CMS: "Never"},
+	}
+	if len(stats.Rules) != len(want) {
+		t.Fatalf("Rules = %+v, want %+v", stats.Rules, want)
+	}
+	for i := range want {
+		if stats.Rules[i] != want[i] {
+			t.Errorf("Rules[%d] = %+v, want %+v", i, stats.Rules[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package core
+
+import (
+	"github.com/kN6jq/fingerScan/internal/model"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultMaxSuggestions 每类候选规则的默认数量上限
+const DefaultMaxSuggestions = 10
+
+var (
+	// suggestTokenRe 从响应体中提取候选关键字
+	suggestTokenRe = regexp.MustCompile(`[A-Za-z0-9_\-./:]{6,80}`)
+	// suggestPairRe 提取 name="value" 形式的属性，属性值中的版本号可以泛化为正则
+	suggestPairRe = regexp.MustCompile(`[A-Za-z][A-Za-z0-9_\-]*=["']?[A-Za-z0-9_\-./:]+["']?`)
+	// suggestNumberRe 候选正则中泛化的数字和版本号
+	suggestNumberRe = regexp.MustCompile(`\d+(?:\.\d+)*`)
+	// suggestHexRe 类似缓存哈希的片段，这类关键字每次发布都会变化
+	suggestHexRe = regexp.MustCompile(`[0-9a-f]{8,}`)
+)
+
+// suggestGenericHeaders 各类服务器都会返回的响应头，不作为候选
+var suggestGenericHeaders = map[string]bool{
+	"Accept-Ranges": true, "Age": true, "Cache-Control": true, "Connection": true,
+	"Content-Encoding": true, "Content-Length": true, "Content-Type": true, "Date": true,
+	"Etag": true, "Expires": true, "Keep-Alive": true, "Last-Modified": true,
+	"Location": true, "Pragma": true, "Set-Cookie": true, "Strict-Transport-Security": true,
+	"Transfer-Encoding": true, "Vary": true,
+}
+
+// suggestGenericTokens 网页中普遍存在的关键字，不作为候选
+var suggestGenericTokens = map[string]bool{
+	"charset": true, "content": true, "stylesheet": true, "javascript": true,
+	"text/javascript": true, "text/css": true, "viewport": true, "width=device-width": true,
+	"initial-scale=1": true, "utf-8": true, "UTF-8": true, "http://": true, "https://": true,
+	"http://www.w3.org/1999/xhtml": true, "X-UA-Compatible": true, "IE=edge": true,
+	"text/html": true, "function": true, "document": true, "window": true,
+}
+
+// SuggestFingerprints 根据同一产品的正样本和无关的负样本生成候选指纹
+// 候选规则在全部正样本上命中且在负样本上都不命中，每类最多max条
+func SuggestFingerprints(cms string, positives, negatives []*model.HTTPResponse, max int) []model.Fingerprint {
+	if len(positives) == 0 {
+		return nil
+	}
+	if max <= 0 {
+		max = DefaultMaxSuggestions
+	}
+
+	var candidates []model.Fingerprint
+	add := func(fp model.Fingerprint) {
+		fp.CMS = cms
+		candidates = append(candidates, fp)
+	}
+
+	first := positives[0]
+	if first.FaviconHash != "" && first.FaviconHash != "0" {
+		add(model.Fingerprint{Method: methodFaviconHash, Location: locationBody, Keywords: []string{first.FaviconHash}})
+	}
+	if first.Title != "" && first.Title != "Not found" {
+		add(model.Fingerprint{Method: methodKeyword, Location: locationTitle, Keywords: []string{first.Title}})
+	}
+	for _, name := range sortedHeaderNames(first.Headers) {
+		if suggestGenericHeaders[name] {
+			continue
+		}
+		for _, value := range first.Headers[name] {
+			if value != "" {
+				add(model.Fingerprint{Method: methodKeyword, Location: headerLocationPrefix + name, Keywords: []string{value}})
+			}
+		}
+		add(model.Fingerprint{Method: methodKeyword, Location: locationHeader, Keywords: []string{name + ": "}})
+	}
+	for _, cookie := range cookieValues(first.Headers) {
+		if i := strings.IndexByte(cookie, '='); i > 0 {
+			add(model.Fingerprint{Method: methodKeyword, Location: locationCookie, Keywords: []string{cookie[:i+1]}})
+		}
+	}
+
+	keywords, patterns := suggestBodyCandidates(positives)
+	for _, keyword := range keywords {
+		add(model.Fingerprint{Method: methodKeyword, Location: locationBody, Keywords: []string{keyword}})
+	}
+	for _, pattern := range patterns {
+		fp := model.Fingerprint{Method: methodRegular, Location: locationBody, Keywords: []string{pattern}}
+		if strings.Contains(pattern, "(") {
+			fp.VersionGroup = 1
+		}
+		add(fp)
+	}
+
+	return verifySuggestions(candidates, positives, negatives, max)
+}
+
+// suggestBodyCandidates 提取在全部正样本响应体中出现的关键字，以及数字不同但结构相同的正则
+func suggestBodyCandidates(positives []*model.HTTPResponse) ([]string, []string) {
+	var keywords, patterns []string
+	seen := make(map[string]bool)
+	for _, token := range bodyTokens(positives[0].Body) {
+		if len(token) < 6 || seen[token] || suggestGenericTokens[token] || suggestHexRe.MatchString(token) {
+			continue
+		}
+		seen[token] = true
+		if !strings.ContainsAny(token, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+			continue
+		}
+
+		if allBodiesContain(positives, token) {
+			keywords = append(keywords, token)
+			continue
+		}
+		// 关键字中的版本号在各样本间不同时，泛化为正则并捕获版本号
+		if pattern := generalizeToken(token); pattern != "" && !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	}
+	return minimalKeywords(keywords), patterns
+}
+
+// bodyTokens 按出现顺序提取响应体中的候选关键字
+// 跳过标签名和属性名，路径同时拆分为各段，属性对用于生成正则
+func bodyTokens(body string) []string {
+	var tokens []string
+	for _, loc := range suggestTokenRe.FindAllStringIndex(body, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && (body[start-1] == '<' || strings.HasSuffix(body[:start], "</")) {
+			continue
+		}
+		if end < len(body) && body[end] == '=' {
+			continue
+		}
+		token := strings.Trim(body[start:end], "-./:")
+		tokens = append(tokens, token)
+		if strings.Contains(token, "/") {
+			tokens = append(tokens, strings.Split(token, "/")...)
+		}
+	}
+	return append(tokens, suggestPairRe.FindAllString(body, -1)...)
+}
+
+// generalizeToken 将关键字中的数字泛化，第一个带点的版本号作为捕获组，没有数字时返回空
+func generalizeToken(token string) string {
+	locs := suggestNumberRe.FindAllStringIndex(token, -1)
+	if len(locs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	last, captured := 0, false
+	for _, loc := range locs {
+		sb.WriteString(regexp.QuoteMeta(token[last:loc[0]]))
+		if !captured && strings.Contains(token[loc[0]:loc[1]], ".") {
+			sb.WriteString(`(\d+(?:\.\d+)+)`)
+			captured = true
+		} else {
+			sb.WriteString(`\d+`)
+		}
+		last = loc[1]
+	}
+	sb.WriteString(regexp.QuoteMeta(token[last:]))
+	return sb.String()
+}
+
+// allBodiesContain 判断全部样本的响应体是否都包含关键字
+func allBodiesContain(responses []*model.HTTPResponse, keyword string) bool {
+	for _, resp := range responses {
+		if !strings.Contains(resp.Body, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// minimalKeywords 去掉包含其他候选关键字的关键字，保留更通用的短关键字
+func minimalKeywords(keywords []string) []string {
+	sorted := append([]string(nil), keywords...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(sorted[i]) < len(sorted[j])
+	})
+	var kept []string
+	for _, keyword := range sorted {
+		redundant := false
+		for _, k := range kept {
+			if strings.Contains(keyword, k) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			kept = append(kept, keyword)
+		}
+	}
+
+	// 恢复在响应体中出现的顺序
+	order := make(map[string]int, len(keywords))
+	for i, keyword := range keywords {
+		order[keyword] = i
+	}
+	sort.Slice(kept, func(i, j int) bool {
+		return order[kept[i]] < order[kept[j]]
+	})
+	return kept
+}
+
+// verifySuggestions 用匹配引擎校验候选规则，保留命中全部正样本且不命中任何负样本的规则
+func verifySuggestions(candidates []model.Fingerprint, positives, negatives []*model.HTTPResponse, max int) []model.Fingerprint {
+	engine := NewEngine(&model.FingerprintDB{Fingerprints: candidates})
+	s := &Scanner{}
+	s.engine.Store(engine)
+	positiveViews := newResponseViews(engine, positives)
+	negativeViews := newResponseViews(engine, negatives)
+
+	var suggestions []model.Fingerprint
+	counts := make(map[string]int)
+	for _, r := range engine.rules {
+		kind := r.fp.Method + "\x00" + r.location
+		if counts[kind] >= max || countMatches(s, r, positiveViews) < len(positiveViews) || countMatches(s, r, negativeViews) > 0 {
+			continue
+		}
+		counts[kind]++
+		suggestions = append(suggestions, r.fp)
+	}
+	return suggestions
+}
+
+// newResponseViews 为每个响应创建视图，关键字扫描结果在各规则间共用
+func newResponseViews(engine *Engine, responses []*model.HTTPResponse) []*responseView {
+	views := make([]*responseView, len(responses))
+	for i, resp := range responses {
+		views[i] = newResponseView(engine, resp)
+	}
+	return views
+}
+
+// countMatches 统计规则命中的响应数
+func countMatches(s *Scanner, r *rule, views []*responseView) int {
+	var n int
+	for _, view := range views {
+		if s.matchFingerprint(r, view) {
+			n++
+		}
+	}
+	return n
+}
+
+// sortedHeaderNames 返回排序后的响应头名称
+func sortedHeaderNames(headers map[string][]string) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -4,14 +4,35 @@ package model
 // HTTPResponse 表示HTTP响应的结构体
 type HTTPResponse struct {
 	URL         string              // 请求URL
+	FinalURL    string              // 跟随跳转后的最终URL
+	Redirects   []string            // 跳转过程中依次返回的Location
 	Body        string              // 响应体
 	Headers     map[string][]string // 响应头
 	Server      string              // 服务器信息
 	StatusCode  int                 // 状态码
 	Length      int                 // 响应长度
 	Title       string              // 网页标题
+	Generator   string              // meta generator 声明的生成器
+	Metas       []string            // meta标签，每项为 name: content
+	Scripts     []string            // script标签的src
+	Links       []string            // link标签的href
+	Icons       []string            // link标签声明的图标地址
+	Manifest    string              // link标签声明的manifest地址
+	Globals     []string            // 内联脚本中赋值的全局变量名
+	FormActions []string            // 表单的action
 	JSURLs      []string            // JavaScript URL列表
+	JS          string              // 抓取的同源脚本内容，多个脚本以换行分隔
+	SourceMaps  []string            // 可以访问的source map地址
 	FaviconHash string              // favicon哈希值
+	Favicons    []Favicon           // 各图标候选的哈希值
+}
+
+// Favicon 表示一个图标候选的哈希值
+type Favicon struct {
+	URL    string `json:"url"`    // 图标地址，data URI只保留逗号前的部分
+	MMH3   string `json:"mmh3"`   // FOFA、Shodan使用的mmh3
+	MD5    string `json:"md5"`    // Hunter、Quake使用的md5
+	SHA256 string `json:"sha256"` // sha256
 }
 
 // ScanResult 表示扫描结果的结构体
@@ -22,14 +43,110 @@ type ScanResult struct {
 	StatusCode int    `json:"statuscode"` // HTTP状态码
 	Length     int    `json:"length"`     // 响应长度
 	Title      string `json:"title"`      // 网页标题
+
+	Generator        string    `json:"generator,omitempty"`         // meta generator 声明的生成器
+	SourceMapExposed bool      `json:"sourcemap_exposed,omitempty"` // 脚本的source map可以访问
+	SourceMaps       []string  `json:"sourcemaps,omitempty"`        // 可以访问的source map地址
+	Favicons         []Favicon `json:"favicons,omitempty"`          // 各图标候选的哈希值
+
+	Packages []Technology `json:"packages,omitempty"` // 打包产物中引用的npm包，置信度较低，不计入cms
+
+	Technologies []Technology `json:"technologies"` // 识别出的技术及版本
+}
+
+// Technology 表示识别出的技术
+type Technology struct {
+	Name       string   `json:"name"`                 // 技术名称
+	Version    string   `json:"version,omitempty"`    // 版本号
+	Category   string   `json:"category,omitempty"`   // 分类
+	Vendor     string   `json:"vendor,omitempty"`     // 厂商
+	Product    string   `json:"product,omitempty"`    // 产品
+	Tags       []string `json:"tags,omitempty"`       // 标签
+	CPE        string   `json:"cpe,omitempty"`        // CPE 2.3
+	Importance string   `json:"importance,omitempty"` // 重要程度
+	Confidence int      `json:"confidence"`           // 置信度 0-100
+
+	Inferred  bool     `json:"inferred,omitempty"`   // 由其他技术推断得出，没有直接命中的规则
+	ImpliedBy []string `json:"implied_by,omitempty"` // 推断出该技术的技术
+
+	Evidence []Evidence `json:"evidence,omitempty"` // 命中的规则及证据
+}
+
+// Evidence 表示一条规则命中的证据
+type Evidence struct {
+	Rule     string `json:"rule"`              // 规则标识
+	Path     string `json:"path,omitempty"`    // 主动探测的路径
+	Location string `json:"location"`          // 命中位置
+	Pattern  string `json:"pattern"`           // 命中的关键字或正则
+	Snippet  string `json:"snippet,omitempty"` // 命中处附近的内容
+}
+
+// RuleStats 表示扫描过程中各规则的命中统计
+type RuleStats struct {
+	Hosts int        `json:"hosts"` // 参与匹配的主机数
+	Rules []RuleStat `json:"rules"` // 按命中主机数降序排列的规则
+}
+
+// RuleStat 表示单条规则的命中统计
+type RuleStat struct {
+	Rule  string  `json:"rule"`            // 规则标识
+	CMS   string  `json:"cms"`             // CMS名称
+	Hits  int     `json:"hits"`            // 命中的主机数
+	Rate  float64 `json:"rate"`            // 命中的主机占比
+	Noisy bool    `json:"noisy,omitempty"` // 命中占比异常高，可能是误报
 }
 
 // Fingerprint 表示CMS指纹特征
 type Fingerprint struct {
-	CMS      string   `json:"cms"`      // CMS名称
-	Method   string   `json:"method"`   // 匹配方法
-	Location string   `json:"location"` // 匹配位置
-	Keywords []string `json:"keyword"`  // 关键字列表
+	ID       string   `json:"id,omitempty"`   // 规则标识，为空时使用 CMS#序号
+	CMS      string   `json:"cms"`            // CMS名称
+	Method   string   `json:"method"`         // 匹配方法
+	Location string   `json:"location"`       // 匹配位置
+	Keywords []string `json:"keyword"`        // 关键字列表
+	Expr     string   `json:"expr,omitempty"` // 布尔表达式，method为expr时使用
+
+	HashAlgorithm string `json:"hash_algorithm,omitempty"` // faviconhash规则的哈希算法: mmh3(默认)、md5 或 sha256
+
+	HeaderFormat string `json:"header_format,omitempty"` // header位置的格式: raw(默认，Name: value) 或 json(旧版JSON序列化)
+
+	VersionGroup int    `json:"version_group,omitempty"` // 第一个正则中作为版本号的捕获组序号
+	VersionRegex string `json:"version_regex,omitempty"` // 单独提取版本号的正则，取第一个捕获组
+	VersionAttr  string `json:"version_attr,omitempty"`  // selector规则从第一个命中元素的该属性提取版本号，同时设置version_regex时再用正则提取
+
+	Category   string   `json:"category,omitempty"`   // 分类，如OA、中间件、JavaScript库
+	Vendor     string   `json:"vendor,omitempty"`     // 厂商
+	Product    string   `json:"product,omitempty"`    // 产品
+	Tags       []string `json:"tags,omitempty"`       // 标签
+	CPE        string   `json:"cpe,omitempty"`        // CPE 2.3 字符串
+	Importance string   `json:"importance,omitempty"` // 重要程度: info/low/medium/high/critical，默认medium
+	Weight     int      `json:"weight,omitempty"`     // 命中时贡献的置信度 1-100，默认100
+
+	Implies  []string `json:"implies,omitempty"`  // 命中时同时存在的技术，如WordPress推断出PHP
+	Excludes []string `json:"excludes,omitempty"` // 命中时不可能同时存在的技术，如Tomcat排除IIS
+
+	Path           string            `json:"path,omitempty"`            // 主动探测路径，为空时匹配首页响应
+	RequestMethod  string            `json:"request_method,omitempty"`  // 探测请求方法，默认GET
+	RequestHeaders map[string]string `json:"request_headers,omitempty"` // 探测请求头
+	RequestBody    string            `json:"request_body,omitempty"`    // 探测请求体
+	StatusCode     int               `json:"status_code,omitempty"`     // 期望的状态码，关键字为空时只匹配状态码
+
+	Fixtures *Fixtures `json:"fixtures,omitempty"` // 测试样本
+}
+
+// Fixtures 表示指纹的测试样本，路径相对于指纹文件所在目录
+type Fixtures struct {
+	Positive []string `json:"positive,omitempty"` // 应当命中的响应样本
+	Negative []string `json:"negative,omitempty"` // 不应命中的响应样本
+}
+
+// Sample 表示保存的响应样本
+type Sample struct {
+	URL        string              `json:"url,omitempty"`       // 请求URL
+	StatusCode int                 `json:"status,omitempty"`    // 状态码，默认200
+	Headers    map[string][]string `json:"headers,omitempty"`   // 响应头
+	Body       string              `json:"body,omitempty"`      // 响应体
+	BodyFile   string              `json:"body_file,omitempty"` // 从文件读取响应体，路径相对于样本文件
+	Favicon    string              `json:"favicon,omitempty"`   // favicon文件，路径相对于样本文件
 }
 
 // FingerprintDB 表示指纹数据库
@@ -0,0 +1,112 @@
+package utils
+
+// AhoCorasick 多模式字符串匹配自动机，一次扫描即可找出所有模式
+type AhoCorasick struct {
+	nodes   []acNode
+	lengths []int // 各模式长度
+}
+
+// acNode 自动机节点
+type acNode struct {
+	next   map[byte]int32 // 子节点
+	fail   int32          // 失配指针
+	output int32          // 在此结束的模式序号，-1表示无
+	dict   int32          // 最近的有输出的后缀节点，-1表示无
+}
+
+// NewAhoCorasick 根据模式列表构建自动机，模式序号即其在列表中的下标
+func NewAhoCorasick(patterns []string) *AhoCorasick {
+	a := &AhoCorasick{nodes: []acNode{newACNode()}, lengths: make([]int, len(patterns))}
+
+	for id, pattern := range patterns {
+		a.lengths[id] = len(pattern)
+		if pattern == "" {
+			continue
+		}
+		cur := int32(0)
+		for i := 0; i < len(pattern); i++ {
+			c := pattern[i]
+			child, ok := a.nodes[cur].next[c]
+			if !ok {
+				child = int32(len(a.nodes))
+				a.nodes = append(a.nodes, newACNode())
+				a.nodes[cur].next[c] = child
+			}
+			cur = child
+		}
+		if a.nodes[cur].output < 0 {
+			a.nodes[cur].output = int32(id)
+		}
+	}
+
+	a.build()
+	return a
+}
+
+// newACNode 创建空节点
+func newACNode() acNode {
+	return acNode{next: make(map[byte]int32), output: -1, dict: -1}
+}
+
+// build 按广度优先计算失配指针和后缀输出链
+func (a *AhoCorasick) build() {
+	queue := make([]int32, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for c, child := range a.nodes[cur].next {
+			fail := a.nodes[cur].fail
+			for {
+				if next, ok := a.nodes[fail].next[c]; ok && next != child {
+					a.nodes[child].fail = next
+					break
+				}
+				if fail == 0 {
+					a.nodes[child].fail = 0
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+
+			f := a.nodes[child].fail
+			if a.nodes[f].output >= 0 {
+				a.nodes[child].dict = f
+			} else {
+				a.nodes[child].dict = a.nodes[f].dict
+			}
+			queue = append(queue, child)
+		}
+	}
+}
+
+// FindAll 扫描文本，返回命中的模式序号及其首次出现的起始偏移
+func (a *AhoCorasick) FindAll(text string) map[int]int {
+	hits := make(map[int]int)
+	cur := int32(0)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		for {
+			if next, ok := a.nodes[cur].next[c]; ok {
+				cur = next
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = a.nodes[cur].fail
+		}
+
+		for n := cur; n > 0; n = a.nodes[n].dict {
+			if id := int(a.nodes[n].output); id >= 0 {
+				if _, ok := hits[id]; !ok {
+					hits[id] = i + 1 - a.lengths[id]
+				}
+			}
+		}
+	}
+	return hits
+}
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAhoCorasickFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		text     string
+		want     map[int]int
+	}{
+		{"classic overlap", []string{"he", "she", "his", "hers"}, "ushers", map[int]int{0: 2, 1: 1, 3: 2}},
+		{"first occurrence only", []string{"ab"}, "xxabab", map[int]int{0: 2}},
+		{"nested suffixes", []string{"abcd", "bc", "c"}, "abcd", map[int]int{0: 0, 1: 1, 2: 2}},
+		{"fail link to shorter prefix", []string{"aab", "ab"}, "aaab", map[int]int{0: 1, 1: 2}},
+		{"byte offsets in utf-8", []string{"致远", "OA"}, "致远OA", map[int]int{0: 0, 1: 6}},
+		{"case sensitive", []string{"nginx"}, "NGINX", map[int]int{}},
+		{"empty pattern never hits", []string{"", "x"}, "x", map[int]int{1: 0}},
+		{"no patterns", nil, "anything", map[int]int{}},
+		{"empty text", []string{"a"}, "", map[int]int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewAhoCorasick(tt.patterns).FindAll(tt.text)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindAll(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
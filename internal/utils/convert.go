@@ -1,11 +1,13 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
 	"log"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 // GetCurrentPath 获取当前程序路径
@@ -34,6 +36,17 @@ func RemoveDuplicates(slice []string) []string {
 	return list
 }
 
+// SplitList 按逗号拆分字符串并去除空白项
+func SplitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // ToJSON 将对象转换为JSON字符串
 func ToJSON(v interface{}) string {
 	data, err := json.Marshal(v)
@@ -43,6 +56,18 @@ func ToJSON(v interface{}) string {
 	return string(data)
 }
 
+// MarshalIndent 生成缩进的JSON，不转义HTML字符以保持规则可读
+func MarshalIndent(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // FromJSON 从JSON字符串解析对象
 func FromJSON(data string, v interface{}) error {
 	return json.Unmarshal([]byte(data), v)
@@ -3,21 +3,19 @@ package utils
 
 import (
 	"bytes"
-	"crypto/tls"
+	"crypto/md5"
+	"crypto/sha256"
 	"encoding/base64"
 	"fmt"
+	"github.com/kN6jq/fingerScan/internal/model"
 	"github.com/twmb/murmur3"
-	"io/ioutil"
 	"math/rand"
-	"net/http"
 	"regexp"
 	"strings"
-	"time"
 )
 
 const (
-	faviconTimeout = 8 * time.Second
-	base64LineLen  = 76
+	base64LineLen = 76
 )
 
 var (
@@ -38,44 +36,25 @@ func RandomUserAgent() string {
 	return userAgents[rand.Intn(len(userAgents))]
 }
 
-// CalculateFaviconHash 计算favicon的哈希值
-func CalculateFaviconHash(url string) string {
-	if url == "" {
-		return "0"
-	}
-
-	favicon, err := fetchFavicon(url)
-	if err != nil {
-		return "0"
-	}
-
+// FaviconHash 计算favicon内容的mmh3哈希值
+func FaviconHash(favicon []byte) string {
 	encodedFavicon := encodeBase64WithLineBreaks(favicon)
 	return calculateMurmurHash(encodedFavicon)
 }
 
-// fetchFavicon 获取favicon图标
-func fetchFavicon(url string) ([]byte, error) {
-	client := &http.Client{
-		Timeout: faviconTimeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		},
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
-
-	resp, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("favicon request failed: %d", resp.StatusCode)
+// FaviconHashes 计算favicon内容的mmh3、md5和sha256哈希值
+func FaviconHashes(url string, favicon []byte) model.Favicon {
+	return model.Favicon{
+		URL:    url,
+		MMH3:   FaviconHash(favicon),
+		MD5:    fmt.Sprintf("%x", md5.Sum(favicon)),
+		SHA256: fmt.Sprintf("%x", sha256.Sum256(favicon)),
 	}
+}
 
-	return ioutil.ReadAll(resp.Body)
+// BodyHashes 计算响应体的mmh3和md5哈希值，mmh3直接对原始内容计算
+func BodyHashes(body []byte) (mmh3, md5sum string) {
+	return calculateMurmurHash(body), fmt.Sprintf("%x", md5.Sum(body))
 }
 
 // encodeBase64WithLineBreaks 使用换行符对数据进行base64编码
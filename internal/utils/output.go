@@ -1,15 +1,16 @@
 package utils
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/gookit/color"
 	"github.com/kN6jq/fingerScan/internal/model"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 )
 
 // PrintResult 打印普通扫描结果
@@ -38,12 +39,115 @@ func PrintColoredResult(result model.ScanResult) {
 	)
 }
 
-// PrintColoredResults 打印重点资产结果
+// PrintEvidence 打印结果中各技术命中的规则证据、暴露的source map和引用的npm包
+func PrintEvidence(result model.ScanResult) {
+	for _, sourceMap := range result.SourceMaps {
+		fmt.Printf("    - sourcemap: %s\n", sourceMap)
+	}
+	if len(result.Packages) > 0 {
+		fmt.Printf("    - npm: %s\n", FormatTechnologies(result.Packages))
+	}
+	for _, tech := range result.Technologies {
+		if tech.Inferred {
+			fmt.Printf("    - %s <= %s (推断)\n", tech.Name, strings.Join(tech.ImpliedBy, ", "))
+		}
+		for _, evidence := range tech.Evidence {
+			location := evidence.Location
+			if evidence.Path != "" {
+				location = evidence.Path + " " + location
+			}
+			fmt.Printf("    - %s [%s] %s: \"%s\" => %s\n",
+				tech.Name,
+				evidence.Rule,
+				location,
+				evidence.Pattern,
+				evidence.Snippet,
+			)
+		}
+	}
+}
+
+// PrintColoredResults 按分类分组打印重点资产结果
 func PrintColoredResults(results []model.ScanResult) {
 	color.RGBStyleFromString("244,211,49").Println("\n重点资产：")
+	for _, group := range GroupByCategory(results) {
+		color.RGBStyleFromString("244,211,49").Printf("[%s]\n", group.Category)
+		for _, result := range group.Results {
+			PrintColoredResult(result)
+		}
+	}
+}
+
+// CategoryGroup 同一分类下的扫描结果
+type CategoryGroup struct {
+	Category string
+	Results  []model.ScanResult
+}
+
+// GroupByCategory 按技术分类对结果分组，包含多个分类的结果会出现在每个分类中
+func GroupByCategory(results []model.ScanResult) []CategoryGroup {
+	var groups []CategoryGroup
+	positions := make(map[string]int)
+	for _, result := range results {
+		categories := ResultCategories(result)
+		if len(categories) == 0 {
+			categories = []string{"未分类"}
+		}
+		for _, category := range categories {
+			pos, ok := positions[category]
+			if !ok {
+				pos = len(groups)
+				positions[category] = pos
+				groups = append(groups, CategoryGroup{Category: category})
+			}
+			groups[pos].Results = append(groups[pos].Results, result)
+		}
+	}
+	return groups
+}
+
+// ResultCategories 返回结果中所有技术的分类
+func ResultCategories(result model.ScanResult) []string {
+	var categories []string
+	for _, tech := range result.Technologies {
+		categories = append(categories, tech.Category)
+	}
+	return RemoveDuplicates(categories)
+}
+
+// FilterResults 保留包含指定分类或标签的结果，两者均为空时不过滤
+func FilterResults(results []model.ScanResult, categories, tags []string) []model.ScanResult {
+	if len(categories) == 0 && len(tags) == 0 {
+		return results
+	}
+
+	wanted := make(map[string]bool)
+	for _, item := range append(append([]string(nil), categories...), tags...) {
+		wanted[strings.ToLower(item)] = true
+	}
+
+	filtered := []model.ScanResult{}
 	for _, result := range results {
-		PrintColoredResult(result)
+		if resultHasAny(result, wanted) {
+			filtered = append(filtered, result)
+		}
 	}
+	return filtered
+}
+
+// resultHasAny 判断结果中是否有技术的分类或标签在集合中
+func resultHasAny(result model.ScanResult, wanted map[string]bool) bool {
+	for _, tech := range result.Technologies {
+		if wanted[strings.ToLower(tech.Category)] {
+			return true
+		}
+		for _, tag := range tech.Tags {
+			if wanted[strings.ToLower(tag)] {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 // SaveResults 保存扫描结果
@@ -59,19 +163,60 @@ func SaveResults(filename string, results []model.ScanResult) error {
 	}
 }
 
+// CheckOutputFormat 检查结果文件的扩展名是否受支持，扫描前调用以免扫描结束后才发现无法保存
+func CheckOutputFormat(filename string) error {
+	switch ext := strings.ToLower(filepath.Ext(filename)); ext {
+	case ".json", ".xlsx":
+		return nil
+	default:
+		return fmt.Errorf("unsupported file format: %s", ext)
+	}
+}
+
 // SaveJSON 保存JSON格式结果
 func SaveJSON(filename string, results []model.ScanResult) error {
-	data, err := json.MarshalIndent(results, "", "  ")
+	data, err := MarshalIndent(results)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, data, 0644)
+}
+
+// SaveRuleStats 保存JSON格式的规则命中统计
+func SaveRuleStats(filename string, stats model.RuleStats) error {
+	data, err := MarshalIndent(stats)
 	if err != nil {
 		return err
 	}
 	return ioutil.WriteFile(filename, data, 0644)
 }
 
+// PrintRuleStats 打印命中过的规则及未命中、可能误报的规则数量
+func PrintRuleStats(stats model.RuleStats) {
+	var fired, noisy int
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\n规则\tCMS\t命中主机\t占比\t\n")
+	for _, stat := range stats.Rules {
+		if stat.Hits == 0 {
+			continue
+		}
+		fired++
+		mark := ""
+		if stat.Noisy {
+			noisy++
+			mark = "可能误报"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%.1f%%\t%s\n", stat.Rule, stat.CMS, stat.Hits, stat.Rate*100, mark)
+	}
+	w.Flush()
+	fmt.Printf("共 %d 个主机，%d 条规则，%d 条命中，%d 条从未命中，%d 条可能误报\n",
+		stats.Hosts, len(stats.Rules), fired, len(stats.Rules)-fired, noisy)
+}
+
 // SaveXLSX 保存XLSX格式结果
 func SaveXLSX(filename string, results []model.ScanResult) error {
 	xlsx := excelize.NewFile()
-	headers := []string{"url", "cms", "server", "statuscode", "length", "title"}
+	headers := []string{"url", "cms", "server", "statuscode", "length", "title", "technologies", "category", "generator", "sourcemap_exposed"}
 
 	for i, header := range headers {
 		col := string(rune('A' + i))
@@ -86,7 +231,30 @@ func SaveXLSX(filename string, results []model.ScanResult) error {
 		xlsx.SetCellValue("Sheet1", "D"+row, result.StatusCode)
 		xlsx.SetCellValue("Sheet1", "E"+row, result.Length)
 		xlsx.SetCellValue("Sheet1", "F"+row, result.Title)
+		xlsx.SetCellValue("Sheet1", "G"+row, FormatTechnologies(result.Technologies))
+		xlsx.SetCellValue("Sheet1", "H"+row, strings.Join(ResultCategories(result), ", "))
+		xlsx.SetCellValue("Sheet1", "I"+row, result.Generator)
+		xlsx.SetCellValue("Sheet1", "J"+row, result.SourceMapExposed)
 	}
 
 	return xlsx.SaveAs(filename)
 }
+
+// FormatTechnologies 将技术列表格式化为 "名称 版本" 并以逗号分隔，置信度不足100时附加置信度，推断出的技术附加标记
+func FormatTechnologies(techs []model.Technology) string {
+	items := make([]string, 0, len(techs))
+	for _, tech := range techs {
+		item := tech.Name
+		if tech.Version != "" {
+			item += " " + tech.Version
+		}
+		if tech.Confidence < 100 {
+			item += fmt.Sprintf(" (%d%%)", tech.Confidence)
+		}
+		if tech.Inferred {
+			item += " (推断)"
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ", ")
+}
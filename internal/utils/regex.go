@@ -3,8 +3,11 @@ package utils
 
 import (
 	"encoding/json"
+	"net/textproto"
 	"regexp"
+	"sort"
 	"strings"
+	"unicode/utf8"
 )
 
 // ContainsAllKeywords 检查字符串是否包含所有关键字
@@ -28,22 +31,76 @@ func MatchesAllPatterns(content string, patterns []string) bool {
 	return true
 }
 
-// ExtractFaviconPaths 提取favicon路径
-func ExtractFaviconPaths(content string) []string {
-	re := regexp.MustCompile(`href="(.*?favicon....)"`)
-	matches := re.FindAllStringSubmatch(content, -1)
+// HeadersToString 将HTTP头转换为字符串
+func HeadersToString(headers map[string][]string) string {
+	data, _ := json.Marshal(headers)
+	return string(data)
+}
+
+// HeadersToRaw 将HTTP头转换为 Name: value\r\n 形式，按名称排序，同名头保持原有顺序
+func HeadersToRaw(headers map[string][]string) string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := textproto.CanonicalMIMEHeaderKey(names[i]), textproto.CanonicalMIMEHeaderKey(names[j])
+		if a != b {
+			return a < b
+		}
+		return names[i] < names[j]
+	})
 
-	var paths []string
-	for _, match := range matches {
-		if len(match) >= 2 {
-			paths = append(paths, match[1])
+	var sb strings.Builder
+	for _, name := range names {
+		canonical := textproto.CanonicalMIMEHeaderKey(name)
+		for _, value := range headers[name] {
+			sb.WriteString(canonical)
+			sb.WriteString(": ")
+			sb.WriteString(value)
+			sb.WriteString("\r\n")
 		}
 	}
-	return paths
+	return sb.String()
 }
 
-// HeadersToString 将HTTP头转换为字符串
-func HeadersToString(headers map[string][]string) string {
-	data, _ := json.Marshal(headers)
-	return string(data)
+// HeaderValues 获取指定响应头的全部取值，名称不区分大小写
+func HeaderValues(headers map[string][]string, name string) []string {
+	if values, ok := headers[textproto.CanonicalMIMEHeaderKey(name)]; ok {
+		return values
+	}
+	var values []string
+	for key, v := range headers {
+		if strings.EqualFold(key, name) {
+			values = append(values, v...)
+		}
+	}
+	return values
+}
+
+// Snippet 截取匹配位置前后radius字节的内容，换行替换为空格
+func Snippet(content string, start, end, radius int) string {
+	from, to := start-radius, end+radius
+	if from < 0 {
+		from = 0
+	}
+	if to > len(content) {
+		to = len(content)
+	}
+	// 避免截断多字节字符
+	for from > 0 && !utf8.RuneStart(content[from]) {
+		from--
+	}
+	for to < len(content) && !utf8.RuneStart(content[to]) {
+		to++
+	}
+
+	snippet := strings.Join(strings.Fields(content[from:to]), " ")
+	if from > 0 {
+		snippet = "..." + snippet
+	}
+	if to < len(content) {
+		snippet += "..."
+	}
+	return snippet
 }